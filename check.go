@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	flag "github.com/spf13/pflag"
+
+	"github.com/sapcc/swift-health-exporter/internal/config"
+	"github.com/sapcc/swift-health-exporter/internal/probe"
+)
+
+// Exit codes and status names of Nagios/Icinga plugins.
+const (
+	checkOK = iota
+	checkWarning
+	checkCritical
+	checkUnknown
+)
+
+var checkStatusNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// runCheck implements the "check" subcommand, which runs a single collector
+// once and reports the result in the format of a Nagios/Icinga plugin. It
+// returns the exit code for the process.
+func runCheck(ctx context.Context, args []string, w io.Writer) int {
+	var (
+		configFile string
+		metric     string
+		warning    string
+		critical   string
+	)
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() {
+		fmt.Fprintln(w, "Usage: swift-health-exporter check [options] <collector>")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&configFile, "config.file", "", "Path to the config file with the collector configuration.")
+	fs.StringVar(&metric, "metric", "", "Only check the values of this metric (default: all metrics of the collector).")
+	fs.StringVarP(&warning, "warning", "w", "", "Warning threshold as a Nagios range (e.g. '10', '10:', '~:10', '@10:20').")
+	fs.StringVarP(&critical, "critical", "c", "", "Critical threshold as a Nagios range.")
+	err := fs.Parse(args)
+	if err != nil {
+		return checkUnknown
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return checkUnknown
+	}
+	collectorName := fs.Arg(0)
+
+	printResult := func(status int, summary string, perfdata []string) int {
+		line := fmt.Sprintf("SWIFT %s %s - %s", strings.ToUpper(collectorName), checkStatusNames[status], summary)
+		if len(perfdata) > 0 {
+			line += " | " + strings.Join(perfdata, " ")
+		}
+		fmt.Fprintln(w, line)
+		return status
+	}
+
+	warningRange, err := parseNagiosRange(warning)
+	if err != nil {
+		return printResult(checkUnknown, "invalid warning threshold: "+err.Error(), nil)
+	}
+	criticalRange, err := parseNagiosRange(critical)
+	if err != nil {
+		return printResult(checkUnknown, "invalid critical threshold: "+err.Error(), nil)
+	}
+
+	// Build the task for the requested collector only.
+	cfg := config.Default()
+	if configFile != "" {
+		cfg, err = config.Load(configFile)
+		if err != nil {
+			return printResult(checkUnknown, err.Error(), nil)
+		}
+	}
+	if _, exists := cfg.Collectors[collectorName]; !exists {
+		return printResult(checkUnknown, "unknown collector: "+collectorName, nil)
+	}
+	for name, cc := range cfg.Collectors {
+		cc.Enabled = name == collectorName
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return printResult(checkUnknown, err.Error(), nil)
+	}
+	f, err := newTaskFactory(cfg)
+	if err != nil {
+		return printResult(checkUnknown, err.Error(), nil)
+	}
+	registry := prometheus.NewRegistry()
+	t := newTarget(registry, probe.Cluster{}, f)
+
+	// Unless a metric is selected explicitly, only the metrics of the task
+	// itself are checked, but not the exit codes and host errors that the
+	// exporter reports about it.
+	checkedMetrics := make(map[string]bool)
+	if metric != "" {
+		checkedMetrics[metric] = true
+	} else {
+		for _, task := range t.collector.Tasks {
+			for _, info := range task.MetricInfos() {
+				checkedMetrics[info.Name] = true
+			}
+		}
+	}
+
+	// Run the task.
+	status := checkOK
+	var problems []string
+	for _, task := range t.collector.Tasks {
+		queries, err := task.UpdateMetrics(ctx)
+		if err != nil {
+			return printResult(checkUnknown, err.Error(), nil)
+		}
+		for _, query := range slices.Sorted(maps.Keys(queries)) {
+			if queries[query] != 0 {
+				status = checkWarning
+				problems = append(problems, fmt.Sprintf("query %q reported errors", query))
+			}
+		}
+	}
+
+	// Evaluate the thresholds.
+	families, err := registry.Gather()
+	if err != nil {
+		return printResult(checkUnknown, err.Error(), nil)
+	}
+	var perfdata []string
+	for _, mf := range families {
+		if mf.GetType() != dto.MetricType_GAUGE || !checkedMetrics[mf.GetName()] {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			label := perfdataLabel(mf.GetName(), m)
+			value := m.GetGauge().GetValue()
+			perfdata = append(perfdata, fmt.Sprintf("'%s'=%s;%s;%s", label, formatCheckValue(value), warning, critical))
+
+			switch {
+			case criticalRange.alerts(value):
+				status = checkCritical
+				problems = append(problems, fmt.Sprintf("%s = %s (critical: %s)", label, formatCheckValue(value), critical))
+			case warningRange.alerts(value):
+				status = max(status, checkWarning)
+				problems = append(problems, fmt.Sprintf("%s = %s (warning: %s)", label, formatCheckValue(value), warning))
+			}
+		}
+	}
+	if len(perfdata) == 0 {
+		return printResult(checkUnknown, "no values reported", nil)
+	}
+
+	summary := fmt.Sprintf("%d values checked", len(perfdata))
+	if len(problems) > 0 {
+		summary = strings.Join(problems, ", ")
+	}
+	return printResult(status, summary, perfdata)
+}
+
+// perfdataLabel returns the label for a perfdata entry, e.g.
+// "swift_cluster_drives_unmounted[10.0.0.1]". Label values are sorted by
+// label name. Perfdata labels must not contain "=" or "'".
+func perfdataLabel(name string, m *dto.Metric) string {
+	if len(m.GetLabel()) == 0 {
+		return name
+	}
+	labels := make(map[string]string, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+	values := make([]string, 0, len(labels))
+	for _, label := range slices.Sorted(maps.Keys(labels)) {
+		values = append(values, labels[label])
+	}
+	return name + "[" + strings.NewReplacer("=", "_", "'", "_").Replace(strings.Join(values, ",")) + "]"
+}
+
+func formatCheckValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// nagiosRange is a threshold range as described in the Monitoring Plugins
+// Development Guidelines. The zero value never alerts.
+type nagiosRange struct {
+	isSet  bool
+	start  float64
+	end    float64
+	inside bool // alert if the value is inside the range (instead of outside)
+}
+
+func parseNagiosRange(input string) (nagiosRange, error) {
+	if input == "" {
+		return nagiosRange{}, nil
+	}
+	r := nagiosRange{isSet: true, end: math.Inf(1)}
+	s := input
+	if strings.HasPrefix(s, "@") {
+		r.inside = true
+		s = s[1:]
+	}
+
+	var err error
+	startStr, endStr, hasColon := strings.Cut(s, ":")
+	if !hasColon {
+		startStr, endStr = "0", s
+	}
+	switch startStr {
+	case "~":
+		r.start = math.Inf(-1)
+	case "":
+		r.start = 0
+	default:
+		r.start, err = strconv.ParseFloat(startStr, 64)
+		if err != nil {
+			return nagiosRange{}, fmt.Errorf("invalid range %q", input)
+		}
+	}
+	if endStr != "" {
+		r.end, err = strconv.ParseFloat(endStr, 64)
+		if err != nil {
+			return nagiosRange{}, fmt.Errorf("invalid range %q", input)
+		}
+	}
+	if r.start > r.end {
+		return nagiosRange{}, errors.New("start of range must not be greater than end")
+	}
+	return r, nil
+}
+
+// alerts returns whether the given value is outside the range (or inside, for
+// ranges starting with "@").
+func (r nagiosRange) alerts(value float64) bool {
+	if !r.isSet {
+		return false
+	}
+	isInside := r.start <= value && value <= r.end
+	return isInside == r.inside
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+//go:generate go run . generate --readme=README.md docs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sapcc/swift-health-exporter/internal/collector"
+	"github.com/sapcc/swift-health-exporter/internal/collector/dispersion"
+	"github.com/sapcc/swift-health-exporter/internal/collector/recon"
+)
+
+// The generated metric tables in the README are enclosed in these markers.
+const (
+	metricDocsBeginMarker = "<!-- BEGIN GENERATED METRICS (do not edit, run `go generate` instead) -->\n"
+	metricDocsEndMarker   = "<!-- END GENERATED METRICS -->\n"
+)
+
+// renderMetricDocs returns the Markdown tables that describe the metrics of
+// all collectors, as they appear in the "Metrics" section of the README.
+func renderMetricDocs() string {
+	var buf bytes.Buffer
+	writeTable := func(heading string, metrics []collector.MetricInfo) {
+		collector.SortMetricInfos(metrics)
+		rows := [][]string{{"Metric", "Type", "Labels", "Description"}}
+		for _, info := range metrics {
+			labels := make([]string, len(info.Labels))
+			for idx, label := range info.Labels {
+				labels[idx] = "`" + label + "`"
+			}
+			rows = append(rows, []string{
+				"`" + info.Name + "`",
+				strings.ToLower(info.Type.String()),
+				strings.Join(labels, ", "),
+				strings.ReplaceAll(info.Help, "|", `\|`),
+			})
+		}
+		fmt.Fprintf(&buf, "%s\n\n", heading)
+		writeMarkdownTable(&buf, rows)
+	}
+
+	// The exit code and host error metrics are listed in the tables of the
+	// collector groups, since they are shared by all collectors of the group.
+	writeTable("### dispersion", append(
+		dispersion.NewReportTask(&dispersion.TaskOpts{}).MetricInfos(),
+		dispersion.TaskExitCodeMetricInfo(),
+	))
+	writeTable("### recon", []collector.MetricInfo{
+		recon.TaskExitCodeMetricInfo(),
+		recon.HostErrorsMetricInfo(),
+	})
+	for _, name := range slices.Sorted(maps.Keys(reconTasks)) {
+		writeTable("#### "+name, reconTasks[name](&recon.TaskOpts{}).MetricInfos())
+	}
+
+	return strings.TrimSpace(buf.String())
+}
+
+// writeMarkdownTable writes a table whose columns are padded to equal width.
+// The first row is the header.
+func writeMarkdownTable(buf *bytes.Buffer, rows [][]string) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for idx, cell := range row {
+			widths[idx] = max(widths[idx], len(cell))
+		}
+	}
+	writeRow := func(cells []string) {
+		buf.WriteString("|")
+		for idx, cell := range cells {
+			fmt.Fprintf(buf, " %-*s |", widths[idx], cell)
+		}
+		buf.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	separators := make([]string, len(widths))
+	for idx, width := range widths {
+		separators[idx] = strings.Repeat("-", width)
+	}
+	writeRow(separators)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	buf.WriteString("\n")
+}
+
+// splitReadme splits the README contents into the parts before, between and
+// after the metric docs markers.
+func splitReadme(contents string) (before, docs, after string, err error) {
+	before, rest, found := strings.Cut(contents, metricDocsBeginMarker)
+	if !found {
+		return "", "", "", errors.New("begin marker for metric docs not found")
+	}
+	docs, after, found = strings.Cut(rest, metricDocsEndMarker)
+	if !found {
+		return "", "", "", errors.New("end marker for metric docs not found")
+	}
+	return before, docs, after, nil
+}
+
+// updateReadme replaces the metric docs in the given README file.
+func updateReadme(path, docs string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	before, _, after, err := splitReadme(string(contents))
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %w", path, err)
+	}
+	result := before + metricDocsBeginMarker + "\n" + docs + "\n\n" + metricDocsEndMarker + after
+	return os.WriteFile(path, []byte(result), 0o644) //nolint:gosec // README is not secret
+}
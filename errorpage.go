@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sapcc/go-bits/httpapi"
+	"github.com/sapcc/go-bits/logg"
+
+	"github.com/sapcc/swift-health-exporter/internal/collector"
+)
+
+// errorsAPI serves the recent errors of the tasks of the /metrics endpoint,
+// as JSON at /api/errors and as an HTML table at /errors.
+type errorsAPI struct {
+	Errors *collector.ErrorLog
+}
+
+func (a errorsAPI) AddTo(r *mux.Router) {
+	r.Methods("GET", "HEAD").Path("/api/errors").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.IdentifyEndpoint(r, "/api/errors")
+		records := a.Errors.Records()
+		if records == nil {
+			records = []collector.ErrorRecord{} // render as [] instead of null
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(records)
+		if err != nil {
+			logg.Error(err.Error())
+		}
+	})
+
+	r.Methods("GET", "HEAD").Path("/errors").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.IdentifyEndpoint(r, "/errors")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := errorPageTemplate.Execute(w, a.Errors.Records())
+		if err != nil {
+			logg.Error(err.Error())
+		}
+	})
+}
+
+var errorPageTemplate = template.Must(template.New("errors").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<html>
+<head><title>Swift Health Exporter - Recent Errors</title></head>
+<body>
+<h1>Recent Errors</h1>
+<p><a href="/">Back</a> | <a href="/api/errors">JSON</a></p>
+{{- if . }}
+<table border="1" cellpadding="4">
+<tr><th>Time</th><th>Task</th><th>Query</th><th>Host</th><th>Error</th><th>Output</th></tr>
+{{- range . }}
+<tr>
+<td>{{ formatTime .Time }}</td>
+<td>{{ .Task }}</td>
+<td><code>{{ .Command }} {{ .Query }}</code></td>
+<td>{{ .Hostname }}</td>
+<td>{{ if .TimedOut }}<b>timeout:</b> {{ end }}{{ .Message }}</td>
+<td>{{ if .Output }}<pre>{{ .Output }}</pre>{{ end }}{{ if .Stderr }}<pre>{{ .Stderr }}</pre>{{ end }}</td>
+</tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No errors have occurred recently.</p>
+{{- end }}
+</body>
+</html>
+`))
@@ -0,0 +1,310 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	flag "github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/sapcc/swift-health-exporter/internal/collector"
+	"github.com/sapcc/swift-health-exporter/internal/collector/dispersion"
+	"github.com/sapcc/swift-health-exporter/internal/collector/recon"
+	"github.com/sapcc/swift-health-exporter/internal/config"
+	"github.com/sapcc/swift-health-exporter/internal/probe"
+	"github.com/sapcc/swift-health-exporter/internal/sink"
+)
+
+// runGenerate implements the "generate" subcommand, which writes a
+// PrometheusRule or a Grafana dashboard for the metrics of the enabled
+// collectors, or the metric tables for the README. It returns the exit code
+// for the process.
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	var (
+		configFile              string
+		allCollectors           bool
+		alertFor                time.Duration
+		replicationAgeThreshold time.Duration
+		readmePath              string
+	)
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: swift-health-exporter generate [options] (rules|dashboard|docs)")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&configFile, "config.file", "", "Path to the config file with the collector configuration.")
+	fs.BoolVar(&allCollectors, "all", false, "Include all collectors instead of only the enabled ones.")
+	fs.DurationVar(&alertFor, "for", 15*time.Minute, "Value of the 'for' field of the generated alerts.")
+	fs.DurationVar(&replicationAgeThreshold, "replication-age-threshold", 2*time.Hour, "Time since the last replication after which the SwiftReplicationAgeHigh alert fires.")
+	fs.StringVar(&readmePath, "readme", "", "Only for 'docs': Update the metric tables in this README file instead of writing them to stdout.")
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || !slices.Contains([]string{"rules", "dashboard", "docs"}, fs.Arg(0)) {
+		fs.Usage()
+		return 2
+	}
+
+	if fs.Arg(0) == "docs" {
+		// The docs always cover all collectors, regardless of the config.
+		docs := renderMetricDocs()
+		if readmePath == "" {
+			_, err = fmt.Fprintln(stdout, docs)
+		} else {
+			err = updateReadme(readmePath, docs)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	cfg := config.Default()
+	if configFile != "" {
+		cfg, err = config.Load(configFile)
+		if err != nil {
+			fmt.Fprintln(stderr, err.Error())
+			return 1
+		}
+	}
+	if allCollectors {
+		for _, cc := range cfg.Collectors {
+			cc.Enabled = true
+		}
+	}
+	metrics, err := describeCollectors(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	switch fs.Arg(0) {
+	case "rules":
+		rules := generatePrometheusRule(metrics, sink.DefaultAlertRules(replicationAgeThreshold), alertFor)
+		enc := yaml.NewEncoder(stdout)
+		enc.SetIndent(2)
+		err = enc.Encode(rules)
+	case "dashboard":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(generateDashboard(metrics))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// describeCollectors returns the metrics (incl. the exit code and host error
+// metrics) of all enabled collectors, keyed by collector name.
+func describeCollectors(cfg config.Config) (map[string][]collector.MetricInfo, error) {
+	result := make(map[string][]collector.MetricInfo)
+	for name, cc := range cfg.Collectors {
+		if !cc.Enabled {
+			continue
+		}
+
+		// Build a target with only this collector enabled. The tasks are never
+		// run, so the executables do not need to exist.
+		single := config.Config{Collectors: make(map[string]*config.CollectorConfig, len(cfg.Collectors))}
+		for otherName := range cfg.Collectors {
+			single.Collectors[otherName] = &config.CollectorConfig{Enabled: otherName == name}
+		}
+		single.Collectors[name].ExecutablePath = cmp.Or(cc.ExecutablePath, "unused")
+		single.Collectors[name].Options = cc.Options
+		f, err := newTaskFactory(single)
+		if err != nil {
+			return nil, err
+		}
+		t := newTarget(prometheus.NewRegistry(), probe.Cluster{}, f)
+
+		var metrics []collector.MetricInfo
+		for _, task := range t.collector.Tasks {
+			metrics = append(metrics, task.MetricInfos()...)
+		}
+		if t.dispersionExitCode != nil {
+			metrics = append(metrics, dispersion.TaskExitCodeMetricInfo())
+		}
+		if t.reconExitCode != nil {
+			metrics = append(metrics, recon.TaskExitCodeMetricInfo(), recon.HostErrorsMetricInfo())
+		}
+		collector.SortMetricInfos(metrics)
+		result[name] = metrics
+	}
+	return result, nil
+}
+
+type prometheusRule struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Groups []prometheusRuleGroup `yaml:"groups"`
+	} `yaml:"spec"`
+}
+
+type prometheusRuleGroup struct {
+	Name  string                `yaml:"name"`
+	Rules []prometheusAlertRule `yaml:"rules"`
+}
+
+type prometheusAlertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var summaryPlaceholderRx = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// generatePrometheusRule converts the alert rules into a PrometheusRule for
+// the prometheus-operator. Rules whose metrics are not reported by any of the
+// given collectors are skipped.
+func generatePrometheusRule(metrics map[string][]collector.MetricInfo, rules []sink.AlertRule, alertFor time.Duration) prometheusRule {
+	isReported := make(map[string]bool)
+	for _, infos := range metrics {
+		for _, info := range infos {
+			isReported[info.Name] = true
+		}
+	}
+
+	group := prometheusRuleGroup{Name: "swift-health.alerts"}
+	for _, rule := range rules {
+		var names []string
+		for _, name := range rule.Metrics {
+			if isReported[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		selector := names[0]
+		if len(names) > 1 {
+			selector = fmt.Sprintf(`{__name__=~"%s"}`, strings.Join(names, "|"))
+		}
+		summary := summaryPlaceholderRx.ReplaceAllStringFunc(rule.Summary, func(match string) string {
+			label := match[1 : len(match)-1]
+			if label == "value" {
+				return "{{ $value }}"
+			}
+			return "{{ $labels." + label + " }}"
+		})
+		group.Rules = append(group.Rules, prometheusAlertRule{
+			Alert:       rule.Name,
+			Expr:        selector + " > " + strconv.FormatFloat(rule.Threshold, 'f', -1, 64),
+			For:         model.Duration(alertFor).String(),
+			Labels:      map[string]string{"severity": rule.Severity},
+			Annotations: map[string]string{"summary": summary},
+		})
+	}
+
+	var result prometheusRule
+	result.APIVersion = "monitoring.coreos.com/v1"
+	result.Kind = "PrometheusRule"
+	result.Metadata.Name = "swift-health-exporter"
+	result.Spec.Groups = []prometheusRuleGroup{group}
+	return result
+}
+
+type grafanaDashboard struct {
+	Title         string         `json:"title"`
+	UID           string         `json:"uid"`
+	SchemaVersion int            `json:"schemaVersion"`
+	Time          map[string]any `json:"time"`
+	Templating    map[string]any `json:"templating"`
+	Panels        []grafanaPanel `json:"panels"`
+}
+
+type grafanaPanel struct {
+	ID          int               `json:"id"`
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Collapsed   *bool             `json:"collapsed,omitempty"`
+	Datasource  map[string]string `json:"datasource,omitempty"`
+	GridPos     map[string]int    `json:"gridPos"`
+	Targets     []grafanaTarget   `json:"targets,omitempty"`
+}
+
+type grafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+// generateDashboard returns a Grafana dashboard with one row per collector and
+// one time series panel per metric.
+func generateDashboard(metrics map[string][]collector.MetricInfo) grafanaDashboard {
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+	dashboard := grafanaDashboard{
+		Title:         "Swift Health",
+		UID:           "swift-health",
+		SchemaVersion: 39,
+		Time:          map[string]any{"from": "now-6h", "to": "now"},
+		Templating: map[string]any{"list": []map[string]any{{
+			"name":  "datasource",
+			"label": "Data source",
+			"type":  "datasource",
+			"query": "prometheus",
+		}}},
+	}
+
+	id, y := 0, 0
+	for _, name := range slices.Sorted(maps.Keys(metrics)) {
+		id++
+		collapsed := false
+		dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+			ID:        id,
+			Type:      "row",
+			Title:     name,
+			Collapsed: &collapsed,
+			GridPos:   map[string]int{"h": 1, "w": 24, "x": 0, "y": y},
+		})
+		y++
+
+		for idx, info := range metrics[name] {
+			legend := make([]string, len(info.Labels))
+			for i, label := range info.Labels {
+				legend[i] = "{{" + label + "}}"
+			}
+			id++
+			dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+				ID:          id,
+				Type:        "timeseries",
+				Title:       info.Name,
+				Description: info.Help,
+				Datasource:  datasource,
+				GridPos:     map[string]int{"h": 8, "w": 12, "x": 12 * (idx % 2), "y": y + 8*(idx/2)},
+				Targets: []grafanaTarget{{
+					RefID:        "A",
+					Expr:         info.Name,
+					LegendFormat: cmp.Or(strings.Join(legend, " "), info.Name),
+				}},
+			})
+		}
+		y += 8 * ((len(metrics[name]) + 1) / 2)
+	}
+	return dashboard
+}
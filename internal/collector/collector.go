@@ -4,15 +4,25 @@
 package collector
 
 import (
+	"sync"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // Collector holds a collection of Task(s) and implements the prometheus.Collector
 // interface.
 //
 // The metric values are updated independently of the Collector through Scraper.
+// If OnDemand is set, this happens in OnDemand.Middleware() when the metrics
+// endpoint is requested, instead of in Scraper.Run().
 type Collector struct {
-	Tasks map[string]Task // map of task name to Task
+	Tasks    map[string]Task // map of task name to Task
+	OnDemand *OnDemandOpts   // optional
+
+	// mu guards Tasks against concurrent ReplaceTasks() calls.
+	mu sync.RWMutex
 }
 
 // New returns a new Collector.
@@ -24,6 +34,8 @@ func New() *Collector {
 
 // Describe implements the prometheus.Collector interface.
 func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	for _, t := range c.Tasks {
 		t.DescribeMetrics(ch)
 	}
@@ -31,7 +43,44 @@ func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect implements the prometheus.Collector interface.
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	for _, t := range c.Tasks {
 		t.CollectMetrics(ch)
 	}
 }
+
+// Gather returns the current metric values of all tasks. Unlike a scrape, this
+// never triggers an update in on-demand mode.
+func (c *Collector) Gather() ([]*dto.MetricFamily, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	registry := prometheus.NewRegistry()
+	for _, t := range c.Tasks {
+		err := registry.Register(taskCollector{t})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return registry.Gather()
+}
+
+// TaskData returns the parsed data from the last update of the given task (see
+// DataReporter). The last return value is false if there is no such task, or
+// if the task does not implement DataReporter.
+func (c *Collector) TaskData(taskName string) (map[string]any, time.Time, bool) {
+	c.mu.RLock()
+	t := c.Tasks[taskName]
+	c.mu.RUnlock()
+
+	if rt, ok := t.(*restoredTask); ok {
+		t = rt.Task
+	}
+	dr, ok := t.(DataReporter)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	data, updatedAt := dr.LastData()
+	return data, updatedAt, true
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"slices"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// MetricInfo describes a metric that is reported by a task, see
+// Task.MetricInfos(). prometheus.Desc does not provide accessors for its
+// fields, so the tasks describe their metrics in this form for the docs and
+// the generated rules and dashboards.
+type MetricInfo struct {
+	Name   string
+	Help   string
+	Type   dto.MetricType
+	Labels []string // variable labels
+}
+
+// GaugeInfo returns the MetricInfo for a gauge with the given variable labels.
+func GaugeInfo(name, help string, labels ...string) MetricInfo {
+	return MetricInfo{
+		Name:   name,
+		Help:   help,
+		Type:   dto.MetricType_GAUGE,
+		Labels: labels,
+	}
+}
+
+// SortMetricInfos sorts the given metrics by name.
+func SortMetricInfos(metrics []MetricInfo) {
+	slices.SortFunc(metrics, func(a, b MetricInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
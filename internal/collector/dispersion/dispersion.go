@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -31,11 +30,28 @@ func GetTaskExitCodeGaugeVec(r prometheus.Registerer) *prometheus.GaugeVec {
 	return gaugeVec
 }
 
+// TaskExitCodeMetricInfo describes the metric from GetTaskExitCodeGaugeVec.
+func TaskExitCodeMetricInfo() collector.MetricInfo {
+	return collector.GaugeInfo("swift_dispersion_task_exit_code", "The exit code for a Swift dispersion report query execution.", "query")
+}
+
+// TaskOpts holds the parameters for the dispersion report task.
+type TaskOpts struct {
+	PathToExecutable string
+	CtxTimeout       time.Duration
+	// ConfigFile is the dispersion config file that is used by
+	// swift-dispersion-report. The swift-dispersion-report default
+	// (/etc/swift/dispersion.conf) is used if empty.
+	ConfigFile string
+	// Executor runs swift-dispersion-report (optional, defaults to the
+	// local host).
+	Executor util.Executor
+}
+
 // ReportTask implements the collector.Task interface.
 type ReportTask struct {
-	ctxTimeout       time.Duration
-	pathToExecutable string
-	cmdArgs          []string
+	opts    *TaskOpts
+	cmdArgs []string
 
 	// errRe is used to match errors and capture the hostname and error message.
 	// E.g.:
@@ -54,12 +70,16 @@ type ReportTask struct {
 }
 
 // NewReportTask returns a collector.Task for ReportTask.
-func NewReportTask(pathToExecutable string, ctxTimeout time.Duration) collector.Task {
+func NewReportTask(opts *TaskOpts) collector.Task {
+	cmdArgs := []string{"--dump-json"}
+	if opts.ConfigFile != "" {
+		cmdArgs = append(cmdArgs, opts.ConfigFile)
+	}
+
 	return &ReportTask{
-		ctxTimeout:       ctxTimeout,
-		pathToExecutable: pathToExecutable,
-		cmdArgs:          []string{"--dump-json"},
-		errRe:            regexp.MustCompile(`(?m)^ERROR:\s*([\d.]+)\S*\s*(.*)$`),
+		opts:    opts,
+		cmdArgs: cmdArgs,
+		errRe:   regexp.MustCompile(`(?m)^ERROR:\s*([\d.]+)\S*\s*(.*)$`),
 		errors: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "swift_dispersion_errors",
@@ -126,6 +146,21 @@ func (t *ReportTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.objectOverlapping.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *ReportTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_dispersion_errors", "The number of errors in the Swift dispersion report."),
+		collector.GaugeInfo("swift_dispersion_container_copies_expected", "Expected container copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_container_copies_found", "Found container copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_container_copies_missing", "Missing container copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_container_overlapping", "Expected container copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_object_copies_expected", "Expected object copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_object_copies_found", "Found object copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_object_copies_missing", "Missing object copies reported by the swift-dispersion-report tool."),
+		collector.GaugeInfo("swift_dispersion_object_overlapping", "Expected object copies reported by the swift-dispersion-report tool."),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *ReportTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.errors.Collect(ch)
@@ -148,7 +183,7 @@ func (t *ReportTask) UpdateMetrics(ctx context.Context) (map[string]int, error)
 		CmdArgs: t.cmdArgs,
 	}
 
-	out, err := util.RunCommandWithTimeout(ctx, t.ctxTimeout, t.pathToExecutable, t.cmdArgs...)
+	out, err := util.RunCommandWithTimeout(ctx, t.opts.Executor, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
 	if err != nil {
 		queries[q] = 1
 		e.Inner = err
@@ -167,7 +202,7 @@ func (t *ReportTask) UpdateMetrics(ctx context.Context) (map[string]int, error)
 			if len(mList) > 0 {
 				e.Inner = errors.New(mList[2])
 				e.Hostname = mList[1]
-				logg.Info(e.Error())
+				collector.ReportError(ctx, e)
 			}
 		}
 		return []byte{}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sapcc/go-bits/logg"
+)
+
+const (
+	// How many errors are kept per task in the ErrorLog.
+	errorLogSize = 20
+	// Command output and stderr are truncated to this many bytes in the ErrorLog.
+	errorLogMaxOutputSize = 4 << 10
+)
+
+// ErrorRecord is an error of a task that has been recorded in the ErrorLog.
+type ErrorRecord struct {
+	Time     time.Time `json:"time"`
+	Task     string    `json:"task"`
+	Command  string    `json:"command,omitempty"`
+	Query    string    `json:"query,omitempty"`
+	Hostname string    `json:"hostname,omitempty"`
+	Message  string    `json:"message"`
+	TimedOut bool      `json:"timed_out,omitempty"`
+	Output   string    `json:"output,omitempty"`
+	Stderr   string    `json:"stderr,omitempty"`
+}
+
+// ErrorLog keeps the most recent errors of each task. Besides the errors that
+// are returned by Task.UpdateMetrics, this includes errors that only affect a
+// part of the output (e.g. a single host) and are reported with ReportError.
+type ErrorLog struct {
+	mu      sync.Mutex
+	records map[string][]ErrorRecord // key = task name, oldest first
+}
+
+// NewErrorLog returns a new ErrorLog.
+func NewErrorLog() *ErrorLog {
+	return &ErrorLog{records: make(map[string][]ErrorRecord)}
+}
+
+// Records returns the recorded errors of all tasks, the most recent first.
+func (l *ErrorLog) Records() []ErrorRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var result []ErrorRecord
+	for _, records := range l.records {
+		result = append(result, records...)
+	}
+	slices.SortStableFunc(result, func(a, b ErrorRecord) int {
+		return cmp.Or(b.Time.Compare(a.Time), strings.Compare(a.Task, b.Task))
+	})
+	return result
+}
+
+// Last returns the most recent error of the given task.
+func (l *ErrorLog) Last(taskName string) (ErrorRecord, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := l.records[taskName]
+	if len(records) == 0 {
+		return ErrorRecord{}, false
+	}
+	return records[len(records)-1], true
+}
+
+func (l *ErrorLog) add(taskName string, at time.Time, err error) {
+	r := ErrorRecord{
+		Time:    at,
+		Task:    taskName,
+		Message: err.Error(),
+	}
+	var e *TaskError
+	if errors.As(err, &e) {
+		r.Command = e.Cmd
+		r.Query = strings.Join(e.CmdArgs, " ")
+		r.Hostname = e.Hostname
+		r.Message = e.Inner.Error()
+		r.TimedOut = e.TimedOut()
+		r.Output = truncateOutput(e.CmdOutput)
+		if cmdErr := e.commandError(); cmdErr != nil {
+			r.Stderr = truncateOutput(cmdErr.Stderr)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records := append(l.records[taskName], r)
+	if len(records) > errorLogSize {
+		records = slices.Clone(records[len(records)-errorLogSize:])
+	}
+	l.records[taskName] = records
+}
+
+// forget discards the recorded errors of the given task.
+func (l *ErrorLog) forget(taskName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.records, taskName)
+}
+
+func truncateOutput(s string) string {
+	if len(s) <= errorLogMaxOutputSize {
+		return s
+	}
+	return strings.ToValidUTF8(s[:errorLogMaxOutputSize], "") + "\n[truncated]"
+}
+
+type errorReporterKey struct{}
+
+// errorReporter is stored in the context of Task.UpdateMetrics by the Scraper.
+type errorReporter struct {
+	log      *ErrorLog
+	taskName string
+	now      func() time.Time
+}
+
+// withErrorReporter returns a context for the UpdateMetrics call of the given
+// task, through which ReportError records into the given ErrorLog.
+func withErrorReporter(ctx context.Context, log *ErrorLog, taskName string, now func() time.Time) context.Context {
+	return context.WithValue(ctx, errorReporterKey{}, errorReporter{log, taskName, now})
+}
+
+// ReportError logs an error that does not fail the whole task (e.g. because it
+// only affects a single host). If the task is run by a Scraper, the error is
+// also recorded in its ErrorLog.
+//
+// The TaskError is not retained, so it can be reused for further errors.
+func ReportError(ctx context.Context, e *TaskError) {
+	logg.Info(e.Error())
+	if r, ok := ctx.Value(errorReporterKey{}).(errorReporter); ok {
+		r.log.add(r.taskName, r.now(), e)
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// scrapeTimeoutHeader is the header in which Prometheus sends the scrape
+// timeout (in seconds) to the scraped target.
+const scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// OnDemandOpts holds the parameters for the synchronous scrape-on-request mode.
+// In this mode, the metric values are updated when Prometheus scrapes the
+// exporter instead of periodically through Scraper.Run.
+type OnDemandOpts struct {
+	Scraper *Scraper
+	// Metric values that are younger than MinAge are served from the cache.
+	MinAge time.Duration
+	// DefaultTimeout is used when a scrape does not provide a timeout header.
+	DefaultTimeout time.Duration
+	// TimeoutOffset is subtracted from the scrape timeout so that there is
+	// enough time left to send the response.
+	TimeoutOffset time.Duration
+}
+
+// Middleware wraps the handler for the metrics endpoint. It updates the metric
+// values before the request is passed on to the given handler, bounded by the
+// timeout that Prometheus sends along with the scrape.
+func (o *OnDemandOpts) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), o.scrapeTimeout(r))
+		defer cancel()
+		o.Scraper.UpdateAllMetricsIfOlderThan(ctx, o.MinAge)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (o *OnDemandOpts) scrapeTimeout(r *http.Request) time.Duration {
+	v := r.Header.Get(scrapeTimeoutHeader)
+	if v == "" {
+		return o.DefaultTimeout
+	}
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil || seconds <= 0 {
+		return o.DefaultTimeout
+	}
+
+	scrapeTimeout := time.Duration(seconds * float64(time.Second))
+	if scrapeTimeout <= o.TimeoutOffset {
+		return scrapeTimeout
+	}
+	return scrapeTimeout - o.TimeoutOffset
+}
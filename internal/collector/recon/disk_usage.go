@@ -6,7 +6,6 @@ package recon
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"os"
 	"regexp"
 	"strconv"
@@ -20,6 +19,8 @@ import (
 
 // DiskUsageTask implements the collector.Task interface.
 type DiskUsageTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -36,7 +37,7 @@ type DiskUsageTask struct {
 func NewDiskUsageTask(opts *TaskOpts) collector.Task {
 	return &DiskUsageTask{
 		opts:          opts,
-		cmdArgs:       []string{fmt.Sprintf("--timeout=%d", opts.HostTimeout), "--diskusage", "--verbose"},
+		cmdArgs:       opts.cmdArgs("--diskusage", "--verbose"),
 		specialCharRe: regexp.MustCompile(`[^a-zA-Z0-9]+`),
 		capacityBytes: prometheus.NewGauge(
 			prometheus.GaugeOpts{
@@ -84,6 +85,17 @@ func (t *DiskUsageTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.fractionalUsageByDisk.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *DiskUsageTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_storage_capacity_bytes", "Capacity storage bytes as reported by the swift-recon tool."),
+		collector.GaugeInfo("swift_cluster_storage_free_bytes", "Free storage bytes as reported by the swift-recon tool."),
+		collector.GaugeInfo("swift_cluster_storage_used_bytes", "Used storage bytes as reported by the swift-recon tool."),
+		collector.GaugeInfo("swift_cluster_storage_used_percent", "Fractional usage as reported by the swift-recon tool."),
+		collector.GaugeInfo("swift_cluster_storage_used_percent_by_disk", "Fractional usage of a disk as reported by the swift-recon tool.", "storage_ip", "disk"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *DiskUsageTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.capacityBytes.Collect(ch)
@@ -97,12 +109,14 @@ func (t *DiskUsageTask) CollectMetrics(ch chan<- prometheus.Metric) {
 func (t *DiskUsageTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	q := util.CmdArgsToStr(t.cmdArgs)
 	queries := map[string]int{q: 0}
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
 	e := &collector.TaskError{
 		Cmd:     "swift-recon",
 		CmdArgs: t.cmdArgs,
 	}
 
-	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
+	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, t.cmdArgs...)
 	if err != nil {
 		queries[q] = 1
 		e.Inner = err
@@ -110,6 +124,7 @@ func (t *DiskUsageTask) UpdateMetrics(ctx context.Context) (map[string]int, erro
 	}
 
 	var totalFree, totalUsed, totalSize flexibleFloat64
+	dataPerHost := make(map[string]any)
 	for hostname, dataBytes := range outputPerHost {
 		var disksData []struct {
 			Device  string          `json:"device"`
@@ -124,9 +139,11 @@ func (t *DiskUsageTask) UpdateMetrics(ctx context.Context) (map[string]int, erro
 			e.Inner = err
 			e.Hostname = hostname
 			e.CmdOutput = string(dataBytes)
-			logg.Info(e.Error())
+			hostErrs.add(hostname, hostErrorReason(dataBytes))
+			collector.ReportError(ctx, e)
 			continue // to next host
 		}
+		dataPerHost[hostname] = disksData
 
 		for _, disk := range disksData {
 			if !(disk.Mounted) {
@@ -148,10 +165,16 @@ func (t *DiskUsageTask) UpdateMetrics(ctx context.Context) (map[string]int, erro
 		}
 	}
 
-	if rawCapStr := os.Getenv("SWIFT_CLUSTER_RAW_CAPACITY_BYTES"); rawCapStr != "" {
+	// The raw_capacity_bytes option takes precedence over the legacy
+	// environment variable.
+	rawCapSource, rawCapStr := "raw_capacity_bytes", t.opts.Options["raw_capacity_bytes"]
+	if rawCapStr == "" {
+		rawCapSource, rawCapStr = "SWIFT_CLUSTER_RAW_CAPACITY_BYTES", os.Getenv("SWIFT_CLUSTER_RAW_CAPACITY_BYTES")
+	}
+	if rawCapStr != "" {
 		rawCap, err := strconv.ParseFloat(rawCapStr, 64)
 		if err != nil {
-			logg.Error("could not parse 'SWIFT_CLUSTER_RAW_CAPACITY_BYTES' value: %s", err.Error())
+			logg.Error("could not parse '%s' value: %s", rawCapSource, err.Error())
 		} else {
 			totalSize = flexibleFloat64(rawCap)
 		}
@@ -159,7 +182,7 @@ func (t *DiskUsageTask) UpdateMetrics(ctx context.Context) (map[string]int, erro
 
 	usageRatio := float64(totalUsed) / float64(totalSize)
 	// The usageRatio value can be greater than 1:
-	// 1. if the manually given total capacity (SWIFT_CLUSTER_RAW_CAPACITY_BYTES) is less than
+	// 1. if the manually given total capacity (raw_capacity_bytes) is less than
 	//    the total capacity reported by 'swift-recon' tool
 	// 2. and the usage is greater than the manually given total capacity.
 	if totalSize == 0 || usageRatio > 1 {
@@ -171,5 +194,6 @@ func (t *DiskUsageTask) UpdateMetrics(ctx context.Context) (map[string]int, erro
 	t.freeBytes.Set(float64(totalFree))
 	t.capacityBytes.Set(float64(totalSize))
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
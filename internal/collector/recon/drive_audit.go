@@ -6,10 +6,8 @@ package recon
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -17,6 +15,8 @@ import (
 
 // DriveAuditTask implements the collector.Task interface.
 type DriveAuditTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -27,7 +27,7 @@ type DriveAuditTask struct {
 func NewDriveAuditTask(opts *TaskOpts) collector.Task {
 	return &DriveAuditTask{
 		opts:    opts,
-		cmdArgs: []string{fmt.Sprintf("--timeout=%d", opts.HostTimeout), "--driveaudit", "--verbose"},
+		cmdArgs: opts.cmdArgs("--driveaudit", "--verbose"),
 		auditErrors: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_drives_audit_errors",
@@ -46,6 +46,13 @@ func (t *DriveAuditTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.auditErrors.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *DriveAuditTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_drives_audit_errors", "Drive audit errors reported by the swift-recon tool.", "storage_ip"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *DriveAuditTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.auditErrors.Collect(ch)
@@ -55,18 +62,21 @@ func (t *DriveAuditTask) CollectMetrics(ch chan<- prometheus.Metric) {
 func (t *DriveAuditTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	q := util.CmdArgsToStr(t.cmdArgs)
 	queries := map[string]int{q: 0}
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
 	e := &collector.TaskError{
 		Cmd:     "swift-recon",
 		CmdArgs: t.cmdArgs,
 	}
 
-	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
+	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, t.cmdArgs...)
 	if err != nil {
 		queries[q] = 1
 		e.Inner = err
 		return queries, e
 	}
 
+	dataPerHost := make(map[string]any)
 	for hostname, dataBytes := range outputPerHost {
 		var data struct {
 			DriveAuditErrors int64 `json:"drive_audit_errors"`
@@ -77,14 +87,17 @@ func (t *DriveAuditTask) UpdateMetrics(ctx context.Context) (map[string]int, err
 			e.Inner = err
 			e.Hostname = hostname
 			e.CmdOutput = string(dataBytes)
-			logg.Info(e.Error())
+			hostErrs.add(hostname, hostErrorReason(dataBytes))
+			collector.ReportError(ctx, e)
 			continue // to next host
 		}
+		dataPerHost[hostname] = data
 
 		t.auditErrors.With(prometheus.Labels{
 			"storage_ip": hostname,
 		}).Set(float64(data.DriveAuditErrors))
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
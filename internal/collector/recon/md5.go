@@ -7,12 +7,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -20,6 +18,8 @@ import (
 
 // MD5Task implements the collector.Task interface.
 type MD5Task struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -29,11 +29,18 @@ type MD5Task struct {
 	notMatched *prometheus.GaugeVec
 }
 
+// md5HostData is the data of a host for one kind of md5sum, as reported by
+// MD5Task.LastData().
+type md5HostData struct {
+	Status string `json:"status"` // "matched", "not_matched" or "error"
+	Output string `json:"output"`
+}
+
 // NewMD5Task returns a collector.Task for MD5Task.
 func NewMD5Task(opts *TaskOpts) collector.Task {
 	return &MD5Task{
 		opts:    opts,
-		cmdArgs: []string{fmt.Sprintf("--timeout=%d", opts.HostTimeout), "--md5", "--verbose"},
+		cmdArgs: opts.cmdArgs("--md5", "--verbose"),
 		all: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_md5_all",
@@ -70,6 +77,16 @@ func (t *MD5Task) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.notMatched.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *MD5Task) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_md5_all", "Sum of matched-, not matched, and errored hosts while checking md5sum(s) as reported by the swift-recon tool.", "kind"),
+		collector.GaugeInfo("swift_cluster_md5_errors", "Error encountered while checking host for md5sum(s) as reported by the swift-recon tool.", "storage_ip", "kind"),
+		collector.GaugeInfo("swift_cluster_md5_matched", "Matched host for md5sum(s) reported by the swift-recon tool.", "storage_ip", "kind"),
+		collector.GaugeInfo("swift_cluster_md5_not_matched", "Not matched host for md5sum(s) reported by the swift-recon tool.", "storage_ip", "kind"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *MD5Task) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.all.Collect(ch)
@@ -101,13 +118,16 @@ var md5OutputBlockRx = regexp.MustCompile(
 func (t *MD5Task) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	q := util.CmdArgsToStr(t.cmdArgs)
 	queries := map[string]int{q: 0}
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
+	dataPerHost := make(map[string]any)
 	e := &collector.TaskError{
 		Cmd:     "swift-recon",
 		CmdArgs: t.cmdArgs,
 	}
 
 	var matchList [][][]byte
-	out, err := util.RunCommandWithTimeout(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
+	out, err := util.RunCommandWithTimeout(ctx, t.opts.Executor, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
 	if err == nil {
 		matchList = md5OutputBlockRx.FindAllSubmatch(out, -1)
 		if len(matchList) == 0 {
@@ -129,7 +149,7 @@ func (t *MD5Task) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 			queries[q] = 1
 			e.Inner = err
 			e.CmdOutput = string(outputBlock)
-			logg.Info(e.Error())
+			collector.ReportError(ctx, e)
 			continue // to next output block
 		}
 
@@ -152,13 +172,17 @@ func (t *MD5Task) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 
 			str := string(dataBytes)
 			var matched, notMatched, errored float64
+			hostData := md5HostData{Output: str}
 			switch {
 			case strings.HasSuffix(str, "matches."):
 				matched = 1
 				all++
-			case strings.Contains(str, `doesn"t match`): // func splitOutputPerHost() changes ' -> "
+				hostData.Status = "matched"
+			case strings.Contains(str, "doesn't match"):
 				notMatched = 1
 				all++
+				hostErrs.add(hostname, "md5_mismatch")
+				hostData.Status = "not_matched"
 			default:
 				if processedErrHost[hostname] {
 					continue // to next host
@@ -167,7 +191,10 @@ func (t *MD5Task) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 				errored = 1
 				all++
 				processedErrHost[hostname] = true
+				hostErrs.add(hostname, hostErrorReason(dataBytes))
+				hostData.Status = "error"
 			}
+			setHostDataPart(dataPerHost, hostname, kind, hostData)
 
 			l := prometheus.Labels{"storage_ip": hostname, "kind": kind}
 			t.matched.With(l).Set(matched)
@@ -177,5 +204,6 @@ func (t *MD5Task) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 		t.all.With(prometheus.Labels{"kind": kind}).Set(all)
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
@@ -0,0 +1,452 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package recon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPythonLiteralDepth limits the nesting of containers in a Python literal.
+// swift-recon output is never nested this deeply.
+const maxPythonLiteralDepth = 100
+
+// pythonLiteralToJSON converts a Python literal, as printed by repr() in
+// Python 2 or 3, into the equivalent JSON. Dicts become objects (with non-string
+// keys converted to strings), lists and tuples become arrays, and True/False
+// become true/false.
+//
+// None becomes the string "None" instead of null. The unmarshaling of
+// swift-recon output (see flexibleFloat64) relies on this to tell missing
+// values apart from zero values.
+func pythonLiteralToJSON(input []byte) ([]byte, error) {
+	p := pythonLiteralParser{input: input}
+	p.skipSpace()
+	err := p.parseValue(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q after value", p.input[p.pos])
+	}
+	return p.output.Bytes(), nil
+}
+
+type pythonLiteralParser struct {
+	input  []byte
+	pos    int
+	output bytes.Buffer
+}
+
+func (p *pythonLiteralParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("cannot parse Python literal at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *pythonLiteralParser) skipSpace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *pythonLiteralParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *pythonLiteralParser) parseValue(depth int) error {
+	if depth > maxPythonLiteralDepth {
+		return p.errorf("nested too deeply")
+	}
+
+	c := p.peek()
+	switch {
+	case p.pos >= len(p.input):
+		return p.errorf("unexpected end of input")
+	case c == '{':
+		return p.parseDict(depth)
+	case c == '[':
+		return p.parseSequence(depth, ']')
+	case c == '(':
+		return p.parseSequence(depth, ')')
+	case c == '\'' || c == '"':
+		s, err := p.parseString(false)
+		if err != nil {
+			return err
+		}
+		return p.writeString(s)
+	case c == '-' || c == '+' || c == '.' || isDigit(c):
+		return p.parseNumber()
+	case isIdentStart(c):
+		return p.parseName()
+	default:
+		return p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *pythonLiteralParser) parseDict(depth int) error {
+	p.pos++ // skip '{'
+	p.output.WriteByte('{')
+	for first := true; ; first = false {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			p.output.WriteByte('}')
+			return nil
+		}
+		if !first {
+			if p.peek() != ',' {
+				return p.errorf("expected ',' or '}' in dict")
+			}
+			p.pos++
+			p.skipSpace()
+			if p.peek() == '}' { // trailing comma
+				continue
+			}
+			p.output.WriteByte(',')
+		}
+
+		err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		if p.peek() != ':' {
+			return p.errorf("expected ':' in dict")
+		}
+		p.pos++
+		p.output.WriteByte(':')
+		p.skipSpace()
+		err = p.parseValue(depth + 1)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseKey parses a dict key. JSON only allows string keys, so other scalar
+// keys are converted into their JSON representation as a string.
+func (p *pythonLiteralParser) parseKey() error {
+	inputStart, start := p.pos, p.output.Len()
+	err := p.parseValue(0)
+	if err != nil {
+		return err
+	}
+	key := string(p.output.Bytes()[start:])
+	switch key[0] {
+	case '"':
+		return nil
+	case '{', '[':
+		p.pos = inputStart
+		return p.errorf("unsupported dict key %s", key)
+	default:
+		p.output.Truncate(start)
+		return p.writeString(key)
+	}
+}
+
+func (p *pythonLiteralParser) parseSequence(depth int, closing byte) error {
+	p.pos++ // skip '[' or '('
+	p.output.WriteByte('[')
+	for first := true; ; first = false {
+		p.skipSpace()
+		if p.peek() == closing {
+			p.pos++
+			p.output.WriteByte(']')
+			return nil
+		}
+		if !first {
+			if p.peek() != ',' {
+				return p.errorf("expected ',' or %q in sequence", closing)
+			}
+			p.pos++
+			p.skipSpace()
+			if p.peek() == closing { // trailing comma
+				continue
+			}
+			p.output.WriteByte(',')
+		}
+		err := p.parseValue(depth + 1)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *pythonLiteralParser) parseNumber() error {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if !isDigit(c) && !isIdentStart(c) && c != '.' && c != '+' && c != '-' {
+			break
+		}
+		// a sign is only allowed at the start and after an exponent
+		if (c == '+' || c == '-') && p.pos > start && p.input[p.pos-1] != 'e' && p.input[p.pos-1] != 'E' {
+			break
+		}
+		p.pos++
+	}
+	text := string(p.input[start:p.pos])
+	text = strings.TrimPrefix(text, "+")
+	// Python 2 prints long integers with an "L" suffix.
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "L"), "l")
+
+	switch strings.TrimPrefix(text, "-") {
+	case "inf", "nan":
+		// float('inf') and float('nan') have no JSON equivalent, but the
+		// string form is understood by strconv.ParseFloat.
+		return p.writeString(text)
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		p.output.WriteString(strconv.FormatInt(i, 10))
+		return nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) || strings.ContainsAny(text, "xXoObB_") {
+		p.pos = start
+		return p.errorf("invalid number %q", text)
+	}
+	p.output.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	return nil
+}
+
+// parseName parses None, True and False, as well as the string prefixes that
+// can precede a quoted string (e.g. u'...').
+func (p *pythonLiteralParser) parseName() error {
+	if p.isStringPrefix() {
+		s, err := p.parseString(true)
+		if err != nil {
+			return err
+		}
+		return p.writeString(s)
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && (isIdentStart(p.input[p.pos]) || isDigit(p.input[p.pos])) {
+		p.pos++
+	}
+	switch name := string(p.input[start:p.pos]); name {
+	case "None":
+		p.output.WriteString(`"None"`)
+	case "True":
+		p.output.WriteString("true")
+	case "False":
+		p.output.WriteString("false")
+	case "inf", "nan":
+		return p.writeString(name)
+	case "null", "true", "false":
+		// JSON literals are not valid Python, but are accepted for leniency.
+		p.output.WriteString(name)
+	default:
+		p.pos = start
+		return p.errorf("unexpected name %q", name)
+	}
+	return nil
+}
+
+// isStringPrefix returns whether the input at the current position is a string
+// prefix (any combination of u, b and r) followed by a quote.
+func (p *pythonLiteralParser) isStringPrefix() bool {
+	for idx := p.pos; idx < len(p.input) && idx < p.pos+3; idx++ {
+		switch p.input[idx] {
+		case 'u', 'U', 'b', 'B', 'r', 'R':
+			continue
+		case '\'', '"':
+			return idx > p.pos
+		}
+		return false
+	}
+	return false
+}
+
+// parseString parses a quoted string. If withPrefix is true, the quote is
+// preceded by a string prefix.
+func (p *pythonLiteralParser) parseString(withPrefix bool) (string, error) {
+	var isBytes, isRaw, isUnicode bool
+	if withPrefix {
+		for c := p.peek(); c != '\'' && c != '"'; c = p.peek() {
+			switch c {
+			case 'b', 'B':
+				isBytes = true
+			case 'u', 'U':
+				isUnicode = true
+			case 'r', 'R':
+				isRaw = true
+			}
+			p.pos++
+		}
+	}
+
+	quote := p.input[p.pos]
+	isTriple := bytes.HasPrefix(p.input[p.pos:], []byte{quote, quote, quote})
+	if isTriple {
+		p.pos += 3
+	} else {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.input) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.input[p.pos]
+		switch {
+		case c == quote && !isTriple:
+			p.pos++
+			return b.String(), nil
+		case c == quote && bytes.HasPrefix(p.input[p.pos:], []byte{quote, quote, quote}):
+			p.pos += 3
+			return b.String(), nil
+		case (c == '\n' || c == '\r') && !isTriple:
+			return "", p.errorf("unterminated string")
+		case c == '\\' && isRaw:
+			// In raw strings, backslashes only prevent the following quote
+			// from terminating the string, but are kept as-is.
+			b.WriteByte(c)
+			p.pos++
+			if p.pos < len(p.input) {
+				b.WriteByte(p.input[p.pos])
+				p.pos++
+			}
+		case c == '\\' && !isUnicode && p.pos+1 < len(p.input) && p.input[p.pos+1] == 'x':
+			err := p.parseByteEscapes(&b)
+			if err != nil {
+				return "", err
+			}
+		case c == '\\':
+			err := p.parseEscape(&b, isBytes)
+			if err != nil {
+				return "", err
+			}
+		case c >= utf8.RuneSelf && isBytes:
+			return "", p.errorf("non-ASCII character in bytes literal")
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseEscape parses an escape sequence within a string. \x escapes in strings
+// without the u prefix are handled by parseByteEscapes instead.
+func (p *pythonLiteralParser) parseEscape(b *strings.Builder, isBytes bool) error {
+	start := p.pos
+	p.pos++ // skip '\'
+	if p.pos >= len(p.input) {
+		return p.errorf("unterminated string")
+	}
+	c := p.input[p.pos]
+	p.pos++
+
+	switch c {
+	case '\n':
+		// line continuation
+	case '\\', '\'', '"':
+		b.WriteByte(c)
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'v':
+		b.WriteByte('\v')
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		end := p.pos - 1
+		for end < len(p.input) && end < p.pos+2 && p.input[end] >= '0' && p.input[end] <= '7' {
+			end++
+		}
+		value, _ := strconv.ParseUint(string(p.input[p.pos-1:end]), 8, 32)
+		p.pos = end
+		b.WriteRune(rune(value))
+	case 'x', 'u', 'U':
+		length := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+		if c != 'x' && isBytes {
+			// not an escape sequence in bytes literals
+			b.WriteByte('\\')
+			b.WriteByte(c)
+			return nil
+		}
+		if p.pos+length > len(p.input) {
+			p.pos = start
+			return p.errorf("truncated \\%c escape", c)
+		}
+		value, err := strconv.ParseUint(string(p.input[p.pos:p.pos+length]), 16, 32)
+		if err != nil || value > utf8.MaxRune {
+			p.pos = start
+			return p.errorf("invalid \\%c escape", c)
+		}
+		p.pos += length
+		b.WriteRune(rune(value))
+	default:
+		// Python keeps unknown escape sequences as-is.
+		b.WriteByte('\\')
+		b.WriteByte(c)
+	}
+	return nil
+}
+
+// parseByteEscapes parses a run of consecutive \x escapes in a string without
+// the u prefix. In Python 2 str and in bytes literals, these denote bytes,
+// which are usually UTF-8 (e.g. in file names). Since JSON strings cannot hold
+// arbitrary bytes, a run that is not valid UTF-8 is interpreted as Latin-1.
+func (p *pythonLiteralParser) parseByteEscapes(b *strings.Builder) error {
+	var buf []byte
+	for p.pos+1 < len(p.input) && p.input[p.pos] == '\\' && p.input[p.pos+1] == 'x' {
+		if p.pos+4 > len(p.input) {
+			return p.errorf("truncated \\x escape")
+		}
+		value, err := strconv.ParseUint(string(p.input[p.pos+2:p.pos+4]), 16, 8)
+		if err != nil {
+			return p.errorf("invalid \\x escape")
+		}
+		buf = append(buf, byte(value))
+		p.pos += 4
+	}
+
+	if utf8.Valid(buf) {
+		b.Write(buf)
+	} else {
+		for _, c := range buf {
+			b.WriteRune(rune(c))
+		}
+	}
+	return nil
+}
+
+func (p *pythonLiteralParser) writeString(s string) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	p.output.Write(buf)
+	return nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
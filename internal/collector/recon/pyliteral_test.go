@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package recon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func TestPythonLiteralToJSON(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected string
+	}{
+		{`{'a': 1, 'b': [2, 3.5], 'c': (4, 5,)}`, `{"a":1,"b":[2,3.5],"c":[4,5]}`},
+		{`{u'device': u'sdb1', u'mounted': True, u'avail': ''}`, `{"device":"sdb1","mounted":true,"avail":""}`},
+		{`{'error': None, 'size': null}`, `{"error":"None","size":null}`},
+		{`["it's", 'say "hi"', 'True', 'None']`, `["it's","say \"hi\"","True","None"]`},
+		{`['\'quoted\'', "\"", '\\x00']`, `["'quoted'","\"","\\x00"]`},
+		{`'\x00versions\x00c'`, `"\u0000versions\u0000c"`},
+		{`u'gr\xf6\xdfe-\u2603 \U0001f600'`, `"größe-☃ 😀"`},
+		{`'gr\xc3\xb6\xc3\x9fe'`, `"größe"`},
+		{`'gr\xf6\xdfe'`, `"größe"`},
+		{`'\101\n\t\q'`, `"A\n\t\\q"`},
+		{`b'\xe4\u00e4'`, `"ä\\u00e4"`},
+		{`r'C:\temp\'s'`, `"C:\\temp\\'s"`},
+		{`{1: -2, 3L: 1e-05, True: inf, None: -nan}`, `{"1":-2,"3":1e-05,"true":"inf","None":"-nan"}`},
+		{`  [ ]  `, `[]`},
+	}
+	for _, tc := range testCases {
+		actual, err := pythonLiteralToJSON([]byte(tc.Input))
+		if err != nil {
+			t.Errorf("unexpected error for %s: %s", tc.Input, err.Error())
+			continue
+		}
+		if string(actual) != tc.Expected {
+			t.Errorf("expected %s to be converted into %s, but got %s", tc.Input, tc.Expected, string(actual))
+		}
+	}
+
+	for _, input := range []string{
+		``,
+		`<urlopen error timed out>`,
+		`HTTP Error 500: Internal Server Error`,
+		`(/path/to/object.ring.gz => 12345) doesn't match on disk md5sum`,
+		`{'a': 1`,
+		`{'a' 1}`,
+		`{[1]: 2}`,
+		`'unterminated`,
+		`'\x4'`,
+		`datetime(2020, 1, 1)`,
+		`0x10`,
+		`1e999`,
+		`[1] [2]`,
+		strings.Repeat("[", 1000),
+	} {
+		_, err := pythonLiteralToJSON([]byte(input))
+		if err == nil {
+			t.Errorf("expected error for %q, but got none", input)
+		}
+	}
+}
+
+func FuzzPythonLiteralToJSON(f *testing.F) {
+	f.Add(`{u'sharding_stats': {u'sharding': {}}}`)
+	f.Add(`[{'device': 'sdb1', 'mounted': False, 'size': None}]`)
+	f.Add(`(1, 2.5, -3L, 'a\'b', "c'd", u'\xe4\u2603\U0001f600', b'\x00')`)
+	f.Add(`{1: True, None: inf}`)
+	f.Fuzz(func(t *testing.T, input string) {
+		output, err := pythonLiteralToJSON([]byte(input))
+		if err == nil && !json.Valid(output) {
+			t.Errorf("%q was converted into invalid JSON: %q", input, string(output))
+		}
+	})
+}
+
+// FuzzShardingContainerNames checks that container and account names survive
+// being printed by swift-recon and parsed by splitOutputPerHost.
+func FuzzShardingContainerNames(f *testing.F) {
+	for _, name := range []string{
+		"container",
+		"it's-a-container",
+		`say "hi"`,
+		`both ' and "`,
+		"True False None",
+		"{'a': [1, 2]}",
+		`back\slash\x00`,
+		"\x00versions\x00container",
+		"größe-☃-😀",
+		"line\nbreak\ttab\r",
+		"-> http://10.0.0.2:6001/recon/sharding: {}",
+	} {
+		f.Add(name, false)
+		f.Add(name, true)
+	}
+	f.Fuzz(func(t *testing.T, name string, python2 bool) {
+		if !utf8.ValidString(name) {
+			t.Skip("swift-recon only prints valid Unicode strings")
+		}
+
+		output := fmt.Sprintf(
+			"-> http://10.0.0.1:6001/recon/sharding: {%[1]s: {%[2]s: {%[3]s: {%[4]s: 1, %[5]s: [{%[6]s: %[8]s, %[7]s: %[8]s}]}}}}\n",
+			pyStringRepr("sharding_stats", python2), pyStringRepr("sharding", python2),
+			pyStringRepr("sharding_candidates", python2), pyStringRepr("found", python2),
+			pyStringRepr("top", python2), pyStringRepr("account", python2),
+			pyStringRepr("container", python2), pyStringRepr(name, python2),
+		)
+		outputPerHost, err := splitOutputPerHost([]byte(output), nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		var data struct {
+			ShardingStats ShardingStats `json:"sharding_stats"`
+		}
+		err = json.Unmarshal(outputPerHost["10.0.0.1"], &data)
+		if err != nil {
+			t.Fatalf("cannot unmarshal %q: %s", output, err.Error())
+		}
+		top := data.ShardingStats.Sharding.ShardingCandidates.Top
+		if len(top) != 1 || top[0].Container != name || top[0].Account != name {
+			t.Errorf("expected container and account %q, but got %#v", name, top)
+		}
+	})
+}
+
+// pyStringRepr quotes a string like Python's repr() does.
+func pyStringRepr(s string, python2 bool) string {
+	quote := '\''
+	if strings.ContainsRune(s, '\'') && !strings.ContainsRune(s, '"') {
+		quote = '"'
+	}
+
+	var b strings.Builder
+	if python2 {
+		b.WriteRune('u')
+	}
+	b.WriteRune(quote)
+	for _, r := range s {
+		switch {
+		case r == quote || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && (python2 || !unicode.IsPrint(r))):
+			switch {
+			case r < 0x100:
+				fmt.Fprintf(&b, `\x%02x`, r)
+			case r < 0x10000:
+				fmt.Fprintf(&b, `\u%04x`, r)
+			default:
+				fmt.Fprintf(&b, `\U%08x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune(quote)
+	return b.String()
+}
@@ -6,10 +6,8 @@ package recon
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -17,6 +15,8 @@ import (
 
 // QuarantinedTask implements the collector.Task interface.
 type QuarantinedTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -29,7 +29,7 @@ type QuarantinedTask struct {
 func NewQuarantinedTask(opts *TaskOpts) collector.Task {
 	return &QuarantinedTask{
 		opts:    opts,
-		cmdArgs: []string{fmt.Sprintf("--timeout=%d", opts.HostTimeout), "--quarantined", "--verbose"},
+		cmdArgs: opts.cmdArgs("--quarantined", "--verbose"),
 		accounts: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_accounts_quarantined",
@@ -60,6 +60,15 @@ func (t *QuarantinedTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.objects.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *QuarantinedTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_accounts_quarantined", "Quarantined accounts reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_quarantined", "Quarantined containers reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_objects_quarantined", "Quarantined objects reported by the swift-recon tool.", "storage_ip"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *QuarantinedTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.accounts.Collect(ch)
@@ -71,18 +80,21 @@ func (t *QuarantinedTask) CollectMetrics(ch chan<- prometheus.Metric) {
 func (t *QuarantinedTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	q := util.CmdArgsToStr(t.cmdArgs)
 	queries := map[string]int{q: 0}
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
 	e := &collector.TaskError{
 		Cmd:     "swift-recon",
 		CmdArgs: t.cmdArgs,
 	}
 
-	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
+	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, t.cmdArgs...)
 	if err != nil {
 		queries[q] = 1
 		e.Inner = err
 		return queries, e
 	}
 
+	dataPerHost := make(map[string]any)
 	for hostname, dataBytes := range outputPerHost {
 		var data struct {
 			Objects    int64 `json:"objects"`
@@ -95,9 +107,11 @@ func (t *QuarantinedTask) UpdateMetrics(ctx context.Context) (map[string]int, er
 			e.Inner = err
 			e.Hostname = hostname
 			e.CmdOutput = string(dataBytes)
-			logg.Info(e.Error())
+			hostErrs.add(hostname, hostErrorReason(dataBytes))
+			collector.ReportError(ctx, e)
 			continue // to next host
 		}
+		dataPerHost[hostname] = data
 
 		l := prometheus.Labels{"storage_ip": hostname}
 		t.accounts.With(l).Set(float64(data.Accounts))
@@ -105,5 +119,6 @@ func (t *QuarantinedTask) UpdateMetrics(ctx context.Context) (map[string]int, er
 		t.objects.With(l).Set(float64(data.Objects))
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
@@ -4,19 +4,49 @@
 package recon
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-)
 
-// IsTest variable is set to true in unit tests.
-var IsTest = false
+	"github.com/sapcc/swift-health-exporter/internal/collector"
+	"github.com/sapcc/swift-health-exporter/internal/util"
+)
 
 // TaskOpts holds common parameters that are used by all recon tasks.
 type TaskOpts struct {
 	PathToExecutable string
 	HostTimeout      int
 	CtxTimeout       time.Duration
+	// SwiftDir is the Swift config directory (incl. the rings) that is used
+	// by swift-recon. The swift-recon default (/etc/swift) is used if empty.
+	SwiftDir string
+	// Options holds collector-specific options (optional).
+	Options map[string]string
+	// Clock is used for computing ages (optional, defaults to the system clock).
+	Clock util.Clock
+	// Executor runs swift-recon (optional, defaults to the local host).
+	Executor util.Executor
+	// HostErrors is the GaugeVec from GetHostErrorsGaugeVec (optional). It is
+	// shared by all recon tasks.
+	HostErrors *prometheus.GaugeVec
+}
+
+func (o *TaskOpts) now() time.Time {
+	if o.Clock == nil {
+		return time.Now()
+	}
+	return o.Clock.Now()
+}
+
+// cmdArgs returns the swift-recon arguments for the given query arguments
+// including the options that are common to all recon tasks.
+func (o *TaskOpts) cmdArgs(args ...string) []string {
+	result := append([]string{fmt.Sprintf("--timeout=%d", o.HostTimeout)}, args...)
+	if o.SwiftDir != "" {
+		result = append(result, "--swiftdir="+o.SwiftDir)
+	}
+	return result
 }
 
 // GetTaskExitCodeGaugeVec returns a *prometheus.GaugeVec for use with recon tasks.
@@ -30,3 +60,26 @@ func GetTaskExitCodeGaugeVec(r prometheus.Registerer) *prometheus.GaugeVec {
 	r.MustRegister(gaugeVec)
 	return gaugeVec
 }
+
+// TaskExitCodeMetricInfo describes the metric from GetTaskExitCodeGaugeVec.
+func TaskExitCodeMetricInfo() collector.MetricInfo {
+	return collector.GaugeInfo("swift_recon_task_exit_code", "The exit code for a Swift Recon query execution.", "query")
+}
+
+// GetHostErrorsGaugeVec returns a *prometheus.GaugeVec for reporting the
+// per-host errors of recon tasks (see TaskOpts.HostErrors).
+func GetHostErrorsGaugeVec(r prometheus.Registerer) *prometheus.GaugeVec {
+	gaugeVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "swift_recon_host_errors",
+			Help: "The number of errors for a storage node in the last run of a Swift Recon task.",
+		}, []string{"task", "storage_ip", "reason"},
+	)
+	r.MustRegister(gaugeVec)
+	return gaugeVec
+}
+
+// HostErrorsMetricInfo describes the metric from GetHostErrorsGaugeVec.
+func HostErrorsMetricInfo() collector.MetricInfo {
+	return collector.GaugeInfo("swift_recon_host_errors", "The number of errors for a storage node in the last run of a Swift Recon task.", "task", "storage_ip", "reason")
+}
@@ -6,11 +6,8 @@ package recon
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -18,6 +15,8 @@ import (
 
 // ReplicationTask implements the collector.Task interface.
 type ReplicationTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -34,10 +33,7 @@ func NewReplicationTask(opts *TaskOpts) collector.Task {
 	return &ReplicationTask{
 		opts: opts,
 		// <server-type> gets substituted in UpdateMetrics().
-		cmdArgs: []string{
-			fmt.Sprintf("--timeout=%d", opts.HostTimeout), "<server-type>",
-			"--replication", "--verbose",
-		},
+		cmdArgs: opts.cmdArgs("<server-type>", "--replication", "--verbose"),
 		accountReplicationAge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_accounts_replication_age",
@@ -86,6 +82,18 @@ func (t *ReplicationTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.objectReplicationDuration.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *ReplicationTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_accounts_replication_age", "Account replication age reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_accounts_replication_duration", "Account replication duration reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_replication_age", "Container replication age reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_replication_duration", "Container replication duration reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_objects_replication_age", "Object replication age reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_objects_replication_duration", "Object replication duration reported by the swift-recon tool.", "storage_ip"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *ReplicationTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.accountReplicationAge.Collect(ch)
@@ -99,6 +107,9 @@ func (t *ReplicationTask) CollectMetrics(ch chan<- prometheus.Metric) {
 // UpdateMetrics implements the collector.Task interface.
 func (t *ReplicationTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	queries := make(map[string]int)
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
+	dataPerHost := make(map[string]any)
 	serverTypes := []string{"account", "container", "object"}
 	for _, server := range serverTypes {
 		var ageTypedDesc, durTypedDesc *prometheus.GaugeVec
@@ -123,8 +134,8 @@ func (t *ReplicationTask) UpdateMetrics(ctx context.Context) (map[string]int, er
 			CmdArgs: cmdArgs,
 		}
 
-		currentTime := float64(time.Now().Unix())
-		outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, cmdArgs...)
+		currentTime := float64(t.opts.now().Unix())
+		outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, cmdArgs...)
 		if err != nil {
 			queries[q] = 1
 			e.Inner = err
@@ -142,15 +153,14 @@ func (t *ReplicationTask) UpdateMetrics(ctx context.Context) (map[string]int, er
 				e.Inner = err
 				e.Hostname = hostname
 				e.CmdOutput = string(dataBytes)
-				logg.Info(e.Error())
+				hostErrs.add(hostname, hostErrorReason(dataBytes))
+				collector.ReportError(ctx, e)
 				continue // to next host
 			}
+			setHostDataPart(dataPerHost, hostname, server, data)
 
 			l := prometheus.Labels{"storage_ip": hostname}
 			if data.ReplicationLast > 0 {
-				if IsTest {
-					currentTime = float64(timeNow().Second())
-				}
 				tDiff := currentTime - float64(data.ReplicationLast)
 				ageTypedDesc.With(l).Set(tDiff)
 			}
@@ -158,5 +168,6 @@ func (t *ReplicationTask) UpdateMetrics(ctx context.Context) (map[string]int, er
 		}
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
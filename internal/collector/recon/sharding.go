@@ -7,9 +7,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -17,6 +18,8 @@ import (
 
 // ShardingTask implements the collector.Task interface.
 type ShardingTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -75,10 +78,7 @@ func NewShardingTask(opts *TaskOpts) collector.Task {
 	return &ShardingTask{
 		opts: opts,
 		// <server-type> gets substituted in UpdateMetrics().
-		cmdArgs: []string{
-			fmt.Sprintf("--timeout=%d", opts.HostTimeout), "container",
-			"--sharding", "--verbose",
-		},
+		cmdArgs: opts.cmdArgs("container", "--sharding", "--verbose"),
 		containerShardingAuditRootAttempted: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_containers_sharding_audit_root_attempted",
@@ -341,6 +341,52 @@ func (t *ShardingTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.containerShardingCandidatesObjectCount.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *ShardingTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_root_attempted", "Container root DB auditor number attempted reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_root_failure", "Container root DB auditor number of failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_root_success", "Container root DB auditor number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_root_has_overlap", "Container root DB auditor has_overlap reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_root_num_overlap", "Container root DB auditor number of overlaps reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_shard_attempted", "Container shard DB auditor number attempted reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_shard_failure", "Container shard DB auditor number of failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_audit_shard_success", "Container shard DB auditor number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_cleaved_attempted", "Container shard cleaved number attempted reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_cleaved_failure", "Container shard cleaved number of failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_cleaved_max_time", "Container shard cleaved max_time reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_cleaved_min_time", "Container shard cleaved min_time reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_cleaved_success", "Container shard cleaved number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_created_attempted", "Container shard created number attempted reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_created_failure", "Container shard created number of failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_created_success", "Container shard created number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_scanned_attempted", "Container shard scanned number attempted reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_scanned_failure", "Container shard scanned number of failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_scanned_max_time", "Container shard scanned max_time reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_scanned_min_time", "Container shard scanned min_time reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_scanned_success", "Container shard scanned number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_misplaced_attempted", "Container sharding stats on misplaced objects reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_misplaced_failure", "Container sharding stats on misplaced objects failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_misplaced_found", "Container sharding stats on misplaced objects number found reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_misplaced_placed", "Container sharding stats on misplaced objects number placed reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_misplaced_success", "Container sharding stats on misplaced objects number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_misplaced_unplaced", "Container sharding stats on misplaced objects reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_visited_attempted", "Container shard visited number attempted reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_visited_completed", "Container shard visited number completed reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_visited_failure", "Container shard visited number of failures reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_visited_skipped", "Container shard visited number skipped reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_visited_success", "Container shard visited number of successes reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_in_progress_active", "Container sharding in progress number of shards active reported by the swift-recon tool.", "storage_ip", "container", "account"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_in_progress_cleaved", "Container sharding in progress number of shards cleaved reported by the swift-recon tool.", "storage_ip", "container", "account"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_in_progress_created", "Container sharding in progress number of shards created reported by the swift-recon tool.", "storage_ip", "container", "account"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_in_progress_error", "Container sharding in progress number of errors reported by the swift-recon tool.", "storage_ip", "container", "account"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_in_progress_found", "Container sharding in progress number found reported by the swift-recon tool.", "storage_ip", "container", "account"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_in_progress_object_count", "Container sharding in progress object count reported by the swift-recon tool.", "storage_ip", "container", "account"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_candidates_found", "Number of container sharding candidates reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_containers_sharding_candidates_object_count", "Container sharding candidates object count reported by the swift-recon tool.", "storage_ip", "account", "container"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 //
 //nolint:dupl
@@ -503,18 +549,21 @@ func (t *ShardingTask) UpdateMetrics(ctx context.Context) (map[string]int, error
 	cmdArgs := t.cmdArgs
 	q := util.CmdArgsToStr(cmdArgs)
 	queries := map[string]int{q: 0}
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
 	e := &collector.TaskError{
 		Cmd:     "swift-recon",
 		CmdArgs: cmdArgs,
 	}
 
-	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, cmdArgs...)
+	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, cmdArgs...)
 	if err != nil {
 		queries[q] = 1
 		e.Inner = err
 		return queries, e
 	}
 
+	dataPerHost := make(map[string]any)
 	for hostname, dataBytes := range outputPerHost {
 		var data struct {
 			ShardingStats ShardingStats `json:"sharding_stats"`
@@ -526,9 +575,11 @@ func (t *ShardingTask) UpdateMetrics(ctx context.Context) (map[string]int, error
 			e.Inner = err
 			e.Hostname = hostname
 			e.CmdOutput = string(dataBytes)
-			logg.Info(e.Error())
+			hostErrs.add(hostname, hostErrorReason(dataBytes))
+			collector.ReportError(ctx, e)
 			continue // to next host
 		}
+		dataPerHost[hostname] = data
 
 		l := prometheus.Labels{"storage_ip": hostname}
 
@@ -572,7 +623,7 @@ func (t *ShardingTask) UpdateMetrics(ctx context.Context) (map[string]int, error
 		t.containerShardingVisitedSuccess.With(l).Set(float64(data.ShardingStats.Sharding.Visited.Success))
 
 		for _, shardingProcess := range data.ShardingStats.Sharding.ShardingInProgress.All {
-			l := prometheus.Labels{"storage_ip": hostname, "container": shardingProcess.Container, "account": shardingProcess.Account}
+			l := prometheus.Labels{"storage_ip": hostname, "container": containerLabel(shardingProcess.Container), "account": shardingProcess.Account}
 			t.containerShardingInProgressActive.With(l).Set(float64(shardingProcess.Active))
 			t.containerShardingInProgressCleaved.With(l).Set(float64(shardingProcess.Cleaved))
 			t.containerShardingInProgressCreated.With(l).Set(float64(shardingProcess.Created))
@@ -588,9 +639,28 @@ func (t *ShardingTask) UpdateMetrics(ctx context.Context) (map[string]int, error
 		t.containerShardingCandidatesFound.With(prometheus.Labels{"storage_ip": hostname}).Set(float64(data.ShardingStats.Sharding.ShardingCandidates.Found))
 
 		for _, shardingCandidate := range data.ShardingStats.Sharding.ShardingCandidates.Top {
-			t.containerShardingCandidatesObjectCount.With(prometheus.Labels{"storage_ip": hostname, "container": shardingCandidate.Container, "account": shardingCandidate.Account}).Set(float64(shardingCandidate.ObjectCount))
+			t.containerShardingCandidatesObjectCount.With(prometheus.Labels{"storage_ip": hostname, "container": containerLabel(shardingCandidate.Container), "account": shardingCandidate.Account}).Set(float64(shardingCandidate.ObjectCount))
 		}
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
+
+// containerLabel returns the container name for use as a label value. Control
+// characters are escaped like in Python's repr(), since Swift uses names like
+// "\x00versions\x00..." for containers in its reserved namespace.
+func containerLabel(name string) string {
+	if !strings.ContainsFunc(name, unicode.IsControl) {
+		return name
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			fmt.Fprintf(&b, `\x%02x`, r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
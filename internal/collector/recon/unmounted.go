@@ -6,10 +6,8 @@ package recon
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -17,6 +15,8 @@ import (
 
 // UnmountedTask implements the collector.Task interface.
 type UnmountedTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -27,7 +27,7 @@ type UnmountedTask struct {
 func NewUnmountedTask(opts *TaskOpts) collector.Task {
 	return &UnmountedTask{
 		opts:    opts,
-		cmdArgs: []string{fmt.Sprintf("--timeout=%d", opts.HostTimeout), "--unmounted", "--verbose"},
+		cmdArgs: opts.cmdArgs("--unmounted", "--verbose"),
 		unmountedDrives: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_drives_unmounted",
@@ -46,6 +46,13 @@ func (t *UnmountedTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.unmountedDrives.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *UnmountedTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_drives_unmounted", "Unmounted drives reported by the swift-recon tool.", "storage_ip"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *UnmountedTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.unmountedDrives.Collect(ch)
@@ -55,18 +62,21 @@ func (t *UnmountedTask) CollectMetrics(ch chan<- prometheus.Metric) {
 func (t *UnmountedTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	q := util.CmdArgsToStr(t.cmdArgs)
 	queries := map[string]int{q: 0}
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
 	e := &collector.TaskError{
 		Cmd:     "swift-recon",
 		CmdArgs: t.cmdArgs,
 	}
 
-	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, t.cmdArgs...)
+	outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, t.cmdArgs...)
 	if err != nil {
 		queries[q] = 1
 		e.Inner = err
 		return queries, e
 	}
 
+	dataPerHost := make(map[string]any)
 	for hostname, dataBytes := range outputPerHost {
 		var disksData []struct {
 			Device string `json:"device"`
@@ -77,13 +87,16 @@ func (t *UnmountedTask) UpdateMetrics(ctx context.Context) (map[string]int, erro
 			e.Inner = err
 			e.Hostname = hostname
 			e.CmdOutput = string(dataBytes)
-			logg.Info(e.Error())
+			hostErrs.add(hostname, hostErrorReason(dataBytes))
+			collector.ReportError(ctx, e)
 			continue // to next host
 		}
+		dataPerHost[hostname] = disksData
 
 		t.unmountedDrives.With(prometheus.Labels{"storage_ip": hostname}).
 			Set(float64(len(disksData)))
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
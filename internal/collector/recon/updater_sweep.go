@@ -6,10 +6,8 @@ package recon
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
 	"github.com/sapcc/swift-health-exporter/internal/util"
@@ -17,6 +15,8 @@ import (
 
 // UpdaterSweepTask implements the collector.Task interface.
 type UpdaterSweepTask struct {
+	lastHostData
+
 	opts    *TaskOpts
 	cmdArgs []string
 
@@ -29,10 +29,7 @@ func NewUpdaterSweepTask(opts *TaskOpts) collector.Task {
 	return &UpdaterSweepTask{
 		opts: opts,
 		// <server-type> gets substituted in UpdateMetrics().
-		cmdArgs: []string{
-			fmt.Sprintf("--timeout=%d", opts.HostTimeout), "<server-type>",
-			"--updater", "--verbose",
-		},
+		cmdArgs: opts.cmdArgs("<server-type>", "--updater", "--verbose"),
 		containerTime: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "swift_cluster_containers_updater_sweep_time",
@@ -57,6 +54,14 @@ func (t *UpdaterSweepTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
 	t.objectTime.Describe(ch)
 }
 
+// MetricInfos implements the collector.Task interface.
+func (t *UpdaterSweepTask) MetricInfos() []collector.MetricInfo {
+	return []collector.MetricInfo{
+		collector.GaugeInfo("swift_cluster_containers_updater_sweep_time", "Container updater sweep time reported by the swift-recon tool.", "storage_ip"),
+		collector.GaugeInfo("swift_cluster_objects_updater_sweep_time", "Object updater sweep time reported by the swift-recon tool.", "storage_ip"),
+	}
+}
+
 // CollectMetrics implements the collector.Task interface.
 func (t *UpdaterSweepTask) CollectMetrics(ch chan<- prometheus.Metric) {
 	t.containerTime.Collect(ch)
@@ -66,6 +71,9 @@ func (t *UpdaterSweepTask) CollectMetrics(ch chan<- prometheus.Metric) {
 // UpdateMetrics implements the collector.Task interface.
 func (t *UpdaterSweepTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
 	queries := make(map[string]int)
+	hostErrs := make(hostErrors)
+	defer hostErrs.report(t.opts, t.Name())
+	dataPerHost := make(map[string]any)
 	serverTypes := []string{"container", "object"}
 	for _, server := range serverTypes {
 		cmdArgs := t.cmdArgs
@@ -77,7 +85,7 @@ func (t *UpdaterSweepTask) UpdateMetrics(ctx context.Context) (map[string]int, e
 			CmdArgs: cmdArgs,
 		}
 
-		outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts.CtxTimeout, t.opts.PathToExecutable, cmdArgs...)
+		outputPerHost, err := getSwiftReconOutputPerHost(ctx, t.opts, cmdArgs...)
 		if err != nil {
 			queries[q] = 1
 			e.Inner = err
@@ -86,8 +94,8 @@ func (t *UpdaterSweepTask) UpdateMetrics(ctx context.Context) (map[string]int, e
 
 		for hostname, dataBytes := range outputPerHost {
 			var data struct {
-				ContainerUpdaterSweepTime float64 `json:"container_updater_sweep"`
-				ObjectUpdaterSweepTime    float64 `json:"object_updater_sweep"`
+				ContainerUpdaterSweepTime float64 `json:"container_updater_sweep,omitempty"`
+				ObjectUpdaterSweepTime    float64 `json:"object_updater_sweep,omitempty"`
 			}
 			err := json.Unmarshal(dataBytes, &data)
 			if err != nil {
@@ -95,9 +103,11 @@ func (t *UpdaterSweepTask) UpdateMetrics(ctx context.Context) (map[string]int, e
 				e.Inner = err
 				e.Hostname = hostname
 				e.CmdOutput = string(dataBytes)
-				logg.Info(e.Error())
+				hostErrs.add(hostname, hostErrorReason(dataBytes))
+				collector.ReportError(ctx, e)
 				continue // to next host
 			}
+			setHostDataPart(dataPerHost, hostname, server, data)
 
 			val := data.ContainerUpdaterSweepTime
 			gaugeVec := t.containerTime
@@ -110,5 +120,6 @@ func (t *UpdaterSweepTask) UpdateMetrics(ctx context.Context) (map[string]int, e
 		}
 	}
 
+	t.setLastData(dataPerHost, t.opts.now())
 	return queries, nil
 }
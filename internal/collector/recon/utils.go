@@ -10,20 +10,15 @@ import (
 	"errors"
 	"regexp"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-health-exporter/internal/util"
 )
 
-const clockSeconds int64 = 1
-
-// timeNow replaces time.Now in unit tests.
-func timeNow() time.Time {
-	return time.Unix(clockSeconds, 0).UTC()
-}
-
 // flexibleFloat64 is used for fields that are sometimes missing, sometimes an
 // integer/float, and sometimes a string.
 type flexibleFloat64 float64
@@ -80,18 +75,12 @@ func splitOutputPerHost(output []byte, cmdArgs []string) (map[string][]byte, err
 
 		logg.Debug("output from command 'swift-recon %s': %s: %s", util.CmdArgsToStr(cmdArgs), hostname, string(data))
 
-		// convert Python literals to JSON (best effort)
-		data = bytes.ReplaceAll(data, []byte(`u'`), []byte(`'`))
-		data = bytes.ReplaceAll(data, []byte(`'`), []byte(`"`))
-		data = bytes.ReplaceAll(data, []byte(`True`), []byte(`true`))
-		data = bytes.ReplaceAll(data, []byte(`False`), []byte(`false`))
-		data = bytes.ReplaceAll(data, []byte(`None`), []byte(`"None"`))
-		data = bytes.ReplaceAll(data, []byte(`""None""`), []byte(`"None"`))
-		// ^ We sometimes observe strings with the value "None".
-		// The None -> "None" replacement introduces double quoting there which we need to compensate for.
-		data = bytes.ReplaceAll(data, []byte(`\x`), []byte(`\\x`))
-		// ^ Swift renames sharding containers to include \x which is interpreted as an invalid escape character.
-		// Prefix with an additional `\` to pass unmarshalling and preserve naming.
+		// Convert Python literals to JSON. Other output (e.g. error messages)
+		// is kept as-is and fails to unmarshal later, which reports it as an error.
+		converted, err := pythonLiteralToJSON(data)
+		if err == nil {
+			data = converted
+		}
 
 		result[hostname] = data
 	}
@@ -99,11 +88,93 @@ func splitOutputPerHost(output []byte, cmdArgs []string) (map[string][]byte, err
 	return result, nil
 }
 
-func getSwiftReconOutputPerHost(ctx context.Context, ctxTimeout time.Duration, pathToExecutable string, cmdArgs ...string) (map[string][]byte, error) {
-	out, err := util.RunCommandWithTimeout(ctx, ctxTimeout, pathToExecutable, cmdArgs...)
+func getSwiftReconOutputPerHost(ctx context.Context, opts *TaskOpts, cmdArgs ...string) (map[string][]byte, error) {
+	out, err := util.RunCommandWithTimeout(ctx, opts.Executor, opts.CtxTimeout, opts.PathToExecutable, cmdArgs...)
 	if err != nil {
 		return nil, err
 	}
 
 	return splitOutputPerHost(out, cmdArgs)
 }
+
+// hostErrors counts the per-host errors during a single run of a recon task.
+type hostErrors map[hostErrorKey]int
+
+type hostErrorKey struct {
+	Hostname string
+	Reason   string
+}
+
+func (e hostErrors) add(hostname, reason string) {
+	e[hostErrorKey{hostname, reason}]++
+}
+
+// report replaces the values of the swift_recon_host_errors metric for the
+// given task with these errors. Hosts that no longer have errors are removed
+// from the metric.
+func (e hostErrors) report(opts *TaskOpts, taskName string) {
+	if opts.HostErrors == nil {
+		return
+	}
+	opts.HostErrors.DeletePartialMatch(prometheus.Labels{"task": taskName})
+	for key, count := range e {
+		opts.HostErrors.With(prometheus.Labels{
+			"task":       taskName,
+			"storage_ip": key.Hostname,
+			"reason":     key.Reason,
+		}).Set(float64(count))
+	}
+}
+
+// hostErrorReason returns the "reason" label of swift_recon_host_errors for
+// the output of a host that could not be parsed. swift-recon prints the error
+// in place of the host's data if the request to the host failed.
+func hostErrorReason(output []byte) string {
+	switch {
+	case bytes.Contains(output, []byte("timed out")):
+		return "timeout"
+	case bytes.Contains(output, []byte("ECONNREFUSED")) || bytes.Contains(output, []byte("Connection refused")):
+		return "connection_refused"
+	case bytes.HasPrefix(output, []byte("<urlopen error")):
+		return "connection_failed"
+	case bytes.HasPrefix(output, []byte("HTTP Error")):
+		return "http_error"
+	default:
+		return "invalid_output"
+	}
+}
+
+// lastHostData holds the data that a task has parsed from the output of each
+// host in its last update. It is embedded in the tasks to implement the
+// collector.DataReporter interface.
+type lastHostData struct {
+	mu        sync.Mutex
+	data      map[string]any // key = hostname
+	updatedAt time.Time
+}
+
+func (d *lastHostData) setLastData(data map[string]any, updatedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data = data
+	d.updatedAt = updatedAt
+}
+
+// setHostDataPart stores a part of the data of a host in the given map, for
+// tasks whose data for a host consists of several parts (e.g. one per server
+// type). The data of each host is a map of part name to data.
+func setHostDataPart(dataPerHost map[string]any, hostname, part string, data any) {
+	parts, ok := dataPerHost[hostname].(map[string]any)
+	if !ok {
+		parts = make(map[string]any)
+		dataPerHost[hostname] = parts
+	}
+	parts[part] = data
+}
+
+// LastData implements the collector.DataReporter interface.
+func (d *lastHostData) LastData() (data map[string]any, updatedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.data, d.updatedAt
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TaskSpec describes a task for use with ReplaceTasks.
+type TaskSpec struct {
+	Task             Task
+	ExitCodeGaugeVec *prometheus.GaugeVec
+	// HostErrorsGaugeVec is the GaugeVec in which the task reports its per-host
+	// errors with its name in the "task" label (optional). These series are
+	// deleted when the task is removed or replaced.
+	HostErrorsGaugeVec *prometheus.GaugeVec
+	Interval           time.Duration // optional
+	// Config is the configuration that Task was built from. It is compared
+	// with the Config of the existing task with the same name to decide
+	// whether that task can be kept.
+	Config any
+}
+
+// ReplaceTasks atomically replaces the tasks of a Collector/Scraper pair with
+// the given ones.
+//
+// If a task with the same name and an equal TaskSpec.Config already exists, the
+// existing task is kept together with its state (metric values, failure count,
+// time of the last update). All other tasks are replaced and updated as soon
+// as possible. The recorded errors of tasks that are removed entirely are
+// discarded.
+func ReplaceTasks(c *Collector, s *Scraper, specs []TaskSpec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	newTasks := make(map[string]Task, len(specs))
+	for _, spec := range specs {
+		name := spec.Task.Name()
+		oldTask, exists := s.Tasks[name]
+		if exists && reflect.DeepEqual(s.configs[name], spec.Config) {
+			newTasks[name] = oldTask
+		} else {
+			newTasks[name] = spec.Task
+			// Make sure that the next on-demand scrape updates the new task.
+			s.lastUpdatedAt = time.Time{}
+		}
+	}
+
+	// Forget the state of all tasks that are removed or replaced.
+	for name, oldTask := range s.Tasks {
+		if newTasks[name] == oldTask {
+			continue
+		}
+		for _, query := range s.queries[name] {
+			s.ExitCodeGaugeVec[name].DeleteLabelValues(query)
+		}
+		if vec := s.hostErrors[name]; vec != nil {
+			vec.DeletePartialMatch(prometheus.Labels{"task": name})
+		}
+		if _, exists := newTasks[name]; !exists {
+			s.Errors.forget(name)
+		}
+		delete(s.queries, name)
+		delete(s.hostErrors, name)
+		delete(s.FailureCount, name)
+		delete(s.lastRunAt, name)
+		delete(s.ExitCodeGaugeVec, name)
+		delete(s.Intervals, name)
+		delete(s.configs, name)
+		delete(s.states, name)
+	}
+
+	oldTasks := s.Tasks
+	c.Tasks = make(map[string]Task, len(newTasks))
+	s.Tasks = make(map[string]Task, len(newTasks))
+	for _, spec := range specs {
+		name := spec.Task.Name()
+		c.Tasks[name] = newTasks[name]
+		s.Tasks[name] = newTasks[name]
+		s.ExitCodeGaugeVec[name] = spec.ExitCodeGaugeVec
+		s.hostErrors[name] = spec.HostErrorsGaugeVec
+		s.Intervals[name] = spec.Interval
+		s.configs[name] = spec.Config
+	}
+
+	s.statusMu.Lock()
+	oldStatus := s.status
+	s.status = make(map[string]TaskStatus, len(newTasks))
+	for name, task := range newTasks {
+		st := TaskStatus{Name: name}
+		if oldTasks[name] == task {
+			st = oldStatus[name]
+		}
+		st.Interval = s.interval(name)
+		s.status[name] = st
+	}
+	s.statusMu.Unlock()
+
+	// Wake up Scraper.Run() so that new tasks do not have to wait until the
+	// next regular update.
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
@@ -5,13 +5,19 @@ package collector
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sapcc/go-bits/logg"
+
+	"github.com/sapcc/swift-health-exporter/internal/util"
 )
 
-// How long to wait before re-running the scraper for all tasks.
+// How long to wait before re-running the scraper for a task, unless a different
+// interval is given in Scraper.Intervals.
 const scrapeInterval = 1 * time.Minute
 
 // Scraper holds a collection of Task(s) and other parameters that are required for
@@ -21,6 +27,31 @@ type Scraper struct {
 	Tasks            map[string]Task                 // key = task name
 	FailureCount     map[string]int                  // map of task name to its failure count
 	ExitCodeGaugeVec map[string]*prometheus.GaugeVec // map of task name to its relevant exit code GaugeVec
+	Intervals        map[string]time.Duration        // map of task name to its update interval (optional)
+	// AfterUpdate is called by Run() after each update cycle (optional).
+	AfterUpdate func(ctx context.Context)
+	// Clock is used for the update intervals and the age of the metric values
+	// (optional, defaults to the system clock).
+	Clock util.Clock
+	// Errors records the recent errors of all tasks.
+	Errors *ErrorLog
+
+	// mu serializes UpdateAllMetrics() calls, since the on-demand mode can
+	// trigger them from concurrent scrapes.
+	mu            sync.Mutex
+	lastUpdatedAt time.Time
+	lastRunAt     map[string]time.Time            // map of task name to the start time of its last update
+	queries       map[string][]string             // map of task name to the queries from its last update
+	hostErrors    map[string]*prometheus.GaugeVec // map of task name to its TaskSpec.HostErrorsGaugeVec
+	configs       map[string]any                  // map of task name to its TaskSpec.Config, see ReplaceTasks()
+	wakeup        chan struct{}
+	stateFile     string               // optional, see RestoreState()
+	states        map[string]taskState // map of task name to its state from the last successful update
+
+	// statusMu guards status separately from mu, so that Status() does not
+	// block while an update is in progress.
+	statusMu sync.Mutex
+	status   map[string]TaskStatus // map of task name to its status, see Status()
 }
 
 // NewScraper returns a new Scraper.
@@ -30,42 +61,108 @@ func NewScraper(maxFailures int) *Scraper {
 		Tasks:            make(map[string]Task),
 		FailureCount:     make(map[string]int),
 		ExitCodeGaugeVec: make(map[string]*prometheus.GaugeVec),
+		Intervals:        make(map[string]time.Duration),
+		Errors:           NewErrorLog(),
+		lastRunAt:        make(map[string]time.Time),
+		queries:          make(map[string][]string),
+		hostErrors:       make(map[string]*prometheus.GaugeVec),
+		configs:          make(map[string]any),
+		wakeup:           make(chan struct{}, 1),
+		states:           make(map[string]taskState),
+		status:           make(map[string]TaskStatus),
 	}
 }
 
-// Run updates the metrics for all tasks periodically as per the scrapeInterval.
+// SetMaxFailures changes the MaxFailures value of a running Scraper.
+func (s *Scraper) SetMaxFailures(maxFailures int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.MaxFailures = maxFailures
+}
+
+// Run updates the metrics for all tasks periodically as per their interval.
 func (s *Scraper) Run(ctx context.Context) {
 	for {
 		if ctx.Err() != nil {
 			return
 		}
 
-		startedAt := time.Now()
 		s.UpdateAllMetrics(ctx)
-		// Slow down if UpdateAllMetrics() finished faster than the desired scrape
-		// interval.
-		sleepDuration := scrapeInterval - time.Since(startedAt)
-		if sleepDuration > 0 {
-			time.Sleep(sleepDuration)
+		if s.AfterUpdate != nil {
+			s.AfterUpdate(ctx)
+		}
+
+		// Slow down until the next task is due for an update.
+		select {
+		case <-ctx.Done():
+		case <-s.wakeup:
+		case <-time.After(s.nextUpdateIn()):
 		}
 	}
 }
 
+// UpdateAllMetrics updates the metric values for all tasks that are due for an
+// update as per their interval.
 func (s *Scraper) UpdateAllMetrics(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateAllMetrics(ctx, func(taskName string) bool {
+		return s.isDue(taskName, s.interval(taskName))
+	})
+}
+
+// UpdateAllMetricsIfOlderThan works like UpdateAllMetrics, but does nothing if
+// the last update finished less than minAge ago. The intervals of the tasks do
+// not apply here: every task whose values are older than minAge is updated.
+//
+// Concurrent callers wait for an update that is already in progress and then
+// reuse its result.
+func (s *Scraper) UpdateAllMetricsIfOlderThan(ctx context.Context, minAge time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.lastUpdatedAt.IsZero() && s.now().Sub(s.lastUpdatedAt) < minAge {
+		return
+	}
+	s.updateAllMetrics(ctx, func(taskName string) bool {
+		return s.isDue(taskName, minAge)
+	})
+}
+
+func (s *Scraper) updateAllMetrics(ctx context.Context, isDue func(taskName string) bool) {
 	for _, t := range s.Tasks {
 		name := t.Name()
+		if !isDue(name) {
+			continue
+		}
+		startedAt := s.now()
+		s.lastRunAt[name] = startedAt
+
 		exitCodeGaugeVec := s.ExitCodeGaugeVec[name]
-		queries, err := t.UpdateMetrics(ctx)
+		queries, err := t.UpdateMetrics(withErrorReporter(ctx, s.Errors, name, s.now))
 		if err == nil {
 			s.FailureCount[name] = 0
+			if s.stateFile != "" {
+				s.snapshot(t, startedAt)
+			}
 		} else {
+			s.Errors.add(name, startedAt, err)
 			s.FailureCount[name]++
 			if s.FailureCount[name] >= s.MaxFailures {
 				logg.Error(err.Error())
 			}
 		}
 
+		s.setStatus(TaskStatus{
+			Name:         name,
+			Interval:     s.interval(name),
+			LastRunAt:    startedAt,
+			LastDuration: s.now().Sub(startedAt),
+			FailureCount: s.FailureCount[name],
+			Failed:       s.FailureCount[name] > 0 && s.FailureCount[name] >= s.MaxFailures,
+		})
+
 		// Update exit code metric(s).
+		s.queries[name] = slices.Collect(maps.Keys(queries))
 		for query, exitCode := range queries {
 			if s.FailureCount[name] < s.MaxFailures {
 				// We only report a non-success exit code (i.e. 1) when the max
@@ -75,4 +172,75 @@ func (s *Scraper) UpdateAllMetrics(ctx context.Context) {
 			exitCodeGaugeVec.WithLabelValues(query).Set(float64(exitCode))
 		}
 	}
+	s.lastUpdatedAt = s.now()
+
+	if s.stateFile != "" {
+		for name, st := range s.states {
+			st.FailureCount = s.FailureCount[name]
+			s.states[name] = st
+		}
+		err := writeStateFile(s.stateFile, s.states)
+		if err != nil {
+			logg.Error("could not write state file: %s", err.Error())
+		}
+	}
+}
+
+// FailedTasks returns the names of all tasks whose failure count has reached
+// MaxFailures, i.e. whose errors are reported in the exit code metrics.
+func (s *Scraper) FailedTasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []string
+	for name, count := range s.FailureCount {
+		if count > 0 && count >= s.MaxFailures {
+			result = append(result, name)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// snapshot records the current metric values of the given task for the state file.
+func (s *Scraper) snapshot(t Task, updatedAt time.Time) {
+	st, err := snapshotTask(t, updatedAt, 0)
+	if err != nil {
+		logg.Error("could not take snapshot of task %s: %s", t.Name(), err.Error())
+		return
+	}
+	s.states[t.Name()] = st
+}
+
+func (s *Scraper) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
+
+func (s *Scraper) interval(taskName string) time.Duration {
+	if interval := s.Intervals[taskName]; interval > 0 {
+		return interval
+	}
+	return scrapeInterval
+}
+
+// isDue returns whether the last update of the given task started at least
+// the given duration ago.
+func (s *Scraper) isDue(taskName string, interval time.Duration) bool {
+	lastRunAt, exists := s.lastRunAt[taskName]
+	return !exists || s.now().Sub(lastRunAt) >= interval
+}
+
+// nextUpdateIn returns the duration until the next task is due for an update.
+func (s *Scraper) nextUpdateIn() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := scrapeInterval
+	for name := range s.Tasks {
+		result = min(result, s.lastRunAt[name].Add(s.interval(name)).Sub(s.now()))
+	}
+	return max(result, 0)
 }
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// state is the content of a state file, see RestoreState().
+type state struct {
+	Tasks map[string]taskState `json:"tasks"` // map of task name to its state
+}
+
+// taskState holds the metric values from the last successful update of a task.
+type taskState struct {
+	UpdatedAt    time.Time     `json:"updated_at"`
+	FailureCount int           `json:"failure_count"`
+	Metrics      []metricState `json:"metrics"`
+}
+
+type metricState struct {
+	Name   string            `json:"name"`
+	Help   string            `json:"help"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// RestoreState loads the state file at the given path and makes the Collector
+// report the metric values from it (with their original timestamps) for every
+// task until that task is updated successfully for the first time. The
+// failure counts of the tasks are restored as well.
+//
+// Afterwards, the Scraper writes the state of all tasks to the same file after
+// each update. If the file does not exist, an error wrapping fs.ErrNotExist is
+// returned, but the Scraper still writes the file from now on.
+func RestoreState(c *Collector, s *Scraper, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s.stateFile = path
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var st state
+	err = json.Unmarshal(buf, &st)
+	if err != nil {
+		return fmt.Errorf("cannot parse state file %s: %w", path, err)
+	}
+
+	for name, ts := range st.Tasks {
+		t, exists := s.Tasks[name]
+		if !exists {
+			continue // collector was disabled in the meantime
+		}
+		metrics, err := ts.constMetrics()
+		if err != nil {
+			return fmt.Errorf("cannot restore state of task %s from %s: %w", name, path, err)
+		}
+
+		rt := &restoredTask{Task: t, metrics: metrics}
+		c.Tasks[name] = rt
+		s.Tasks[name] = rt
+		s.FailureCount[name] = ts.FailureCount
+		s.states[name] = ts
+	}
+	return nil
+}
+
+func (ts taskState) constMetrics() ([]prometheus.Metric, error) {
+	result := make([]prometheus.Metric, 0, len(ts.Metrics))
+	for _, ms := range ts.Metrics {
+		labelNames := slices.Sorted(maps.Keys(ms.Labels))
+		labelValues := make([]string, len(labelNames))
+		for idx, name := range labelNames {
+			labelValues[idx] = ms.Labels[name]
+		}
+		desc := prometheus.NewDesc(ms.Name, ms.Help, labelNames, nil)
+		m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, ms.Value, labelValues...)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, prometheus.NewMetricWithTimestamp(ts.UpdatedAt, m))
+	}
+	return result, nil
+}
+
+// snapshotTask returns the current metric values of the given task.
+func snapshotTask(t Task, updatedAt time.Time, failureCount int) (taskState, error) {
+	registry := prometheus.NewRegistry()
+	err := registry.Register(taskCollector{t})
+	if err != nil {
+		return taskState{}, err
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		return taskState{}, err
+	}
+
+	result := taskState{UpdatedAt: updatedAt, FailureCount: failureCount}
+	for _, mf := range families {
+		// metricState only holds a single value, and constMetrics restores
+		// all metrics as gauges.
+		if mf.GetType() != dto.MetricType_GAUGE {
+			return taskState{}, fmt.Errorf("metric %s is a %s, but only gauges can be saved in the state file",
+				mf.GetName(), strings.ToLower(mf.GetType().String()))
+		}
+		for _, m := range mf.GetMetric() {
+			ms := metricState{
+				Name:  mf.GetName(),
+				Help:  mf.GetHelp(),
+				Value: m.GetGauge().GetValue(),
+			}
+			if len(m.GetLabel()) > 0 {
+				ms.Labels = make(map[string]string, len(m.GetLabel()))
+				for _, lp := range m.GetLabel() {
+					ms.Labels[lp.GetName()] = lp.GetValue()
+				}
+			}
+			result.Metrics = append(result.Metrics, ms)
+		}
+	}
+	return result, nil
+}
+
+// writeStateFile writes the given task states to the state file. The file is
+// replaced atomically, so that a crash cannot leave a truncated file behind.
+func writeStateFile(path string, states map[string]taskState) error {
+	buf, err := json.Marshal(state{Tasks: states})
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, buf, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// taskCollector adapts a Task to the prometheus.Collector interface.
+type taskCollector struct {
+	Task
+}
+
+func (t taskCollector) Describe(ch chan<- *prometheus.Desc) { t.DescribeMetrics(ch) }
+func (t taskCollector) Collect(ch chan<- prometheus.Metric) { t.CollectMetrics(ch) }
+
+// restoredTask wraps a Task and reports the restored metric values instead of
+// the ones from the Task until the Task is updated successfully.
+type restoredTask struct {
+	Task
+	mu      sync.Mutex
+	metrics []prometheus.Metric // nil after the first successful update
+}
+
+// CollectMetrics implements the Task interface.
+func (t *restoredTask) CollectMetrics(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	metrics := t.metrics
+	t.mu.Unlock()
+
+	if metrics == nil {
+		t.Task.CollectMetrics(ch)
+		return
+	}
+	for _, m := range metrics {
+		ch <- m
+	}
+}
+
+// UpdateMetrics implements the Task interface.
+func (t *restoredTask) UpdateMetrics(ctx context.Context) (map[string]int, error) {
+	queries, err := t.Task.UpdateMetrics(ctx)
+	if err == nil {
+		t.mu.Lock()
+		t.metrics = nil
+		t.mu.Unlock()
+	}
+	return queries, err
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// staticTask is a Task that reports the given metrics.
+type staticTask struct {
+	collectors []prometheus.Collector
+}
+
+func (t staticTask) Name() string {
+	return "static"
+}
+
+func (t staticTask) DescribeMetrics(ch chan<- *prometheus.Desc) {
+	for _, c := range t.collectors {
+		c.Describe(ch)
+	}
+}
+
+func (t staticTask) MetricInfos() []MetricInfo {
+	return nil
+}
+
+func (t staticTask) CollectMetrics(ch chan<- prometheus.Metric) {
+	for _, c := range t.collectors {
+		c.Collect(ch)
+	}
+}
+
+func (t staticTask) UpdateMetrics(context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+func TestSnapshotTask(t *testing.T) {
+	gaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "swift_test_gauge", Help: "Test gauge."}, []string{"storage_ip"})
+	gaugeVec.WithLabelValues("10.0.0.1").Set(42)
+	updatedAt := time.Unix(1700000000, 0).UTC()
+
+	// Gauges are saved with their labels...
+	st, err := snapshotTask(staticTask{[]prometheus.Collector{gaugeVec}}, updatedAt, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.UpdatedAt.Equal(updatedAt) || st.FailureCount != 2 || len(st.Metrics) != 1 {
+		t.Fatalf("unexpected snapshot: %+v", st)
+	}
+	ms := st.Metrics[0]
+	if ms.Name != "swift_test_gauge" || ms.Help != "Test gauge." || ms.Value != 42 || len(ms.Labels) != 1 || ms.Labels["storage_ip"] != "10.0.0.1" {
+		t.Errorf("unexpected metric in snapshot: %+v", ms)
+	}
+
+	// ...and restored as gauges with the original timestamp.
+	metrics, err := st.constMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected one restored metric, got %d", len(metrics))
+	}
+	var m dto.Metric
+	err = metrics[0].Write(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Gauge == nil || m.GetGauge().GetValue() != 42 || m.GetTimestampMs() != updatedAt.UnixMilli() {
+		t.Errorf("unexpected restored metric: %v", &m)
+	}
+
+	// Other metric types would be restored with the wrong type, or lose
+	// values, so they are rejected.
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "swift_test_total", Help: "Test counter."})
+	_, err = snapshotTask(staticTask{[]prometheus.Collector{gaugeVec, counter}}, updatedAt, 0)
+	if expected := "metric swift_test_total is a counter, but only gauges can be saved in the state file"; err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package collector
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TaskStatus describes the state of a task in a Scraper.
+type TaskStatus struct {
+	Name         string
+	Interval     time.Duration
+	LastRunAt    time.Time     // zero if the task has not run yet
+	LastDuration time.Duration // duration of the last run
+	FailureCount int
+	// Failed is true if FailureCount has reached MaxFailures, i.e. if the
+	// error is reported in the exit code metrics.
+	Failed bool
+	// LastError is the most recent error of the task in the ErrorLog (incl.
+	// errors that only affected a single host), or nil if there is none.
+	LastError *ErrorRecord
+}
+
+// Status returns the status of all tasks, sorted by name.
+//
+// Unlike most other methods, this does not wait for an update that is in
+// progress, so the status of the tasks that are currently running is the one
+// from their previous update.
+func (s *Scraper) Status() []TaskStatus {
+	s.statusMu.Lock()
+	result := slices.Collect(maps.Values(s.status))
+	s.statusMu.Unlock()
+
+	for idx, st := range result {
+		if r, exists := s.Errors.Last(st.Name); exists {
+			result[idx].LastError = &r
+		}
+	}
+	slices.SortFunc(result, func(a, b TaskStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// setStatus replaces the status of the given task.
+func (s *Scraper) setStatus(st TaskStatus) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.status[st.Name] = st
+}
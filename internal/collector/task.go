@@ -5,9 +5,13 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sapcc/swift-health-exporter/internal/util"
 )
 
 // Task represents a collector task that deals with a specific set of metrics, their
@@ -15,14 +19,27 @@ import (
 type Task interface {
 	Name() string
 	DescribeMetrics(ch chan<- *prometheus.Desc)
+	// MetricInfos returns the definitions of the metrics that are described
+	// by DescribeMetrics.
+	MetricInfos() []MetricInfo
 	CollectMetrics(ch chan<- prometheus.Metric)
 	// UpdateMetrics returns a map of query to its exit code, and an error.
 	UpdateMetrics(ctx context.Context) (queries map[string]int, err error)
 }
 
+// DataReporter is an optional interface for tasks that can report the data
+// that they have parsed from the command output, including the parts that are
+// not reported as metrics.
+type DataReporter interface {
+	// LastData returns the parsed data from the last update for each host, and
+	// the time of that update. If the task has not been updated successfully
+	// yet, data is nil.
+	LastData() (data map[string]any, updatedAt time.Time)
+}
+
 // TaskError is the error type that a task can return.
 type TaskError struct {
-	Inner     error
+	Inner     error // a *util.CommandError if running the command failed
 	Cmd       string
 	CmdArgs   []string
 	CmdOutput string // optional
@@ -38,5 +55,25 @@ func (e *TaskError) Error() string {
 	if e.CmdOutput != "" {
 		s += "output follows:\n" + e.CmdOutput
 	}
+	if cmdErr := e.commandError(); cmdErr != nil && cmdErr.Stderr != "" {
+		s += "\nstderr follows:\n" + cmdErr.Stderr
+	}
 	return s
 }
+
+// TimedOut returns whether the task failed because the command ran into its
+// timeout, as opposed to failing by itself (e.g. with a non-zero exit code).
+func (e *TaskError) TimedOut() bool {
+	cmdErr := e.commandError()
+	return cmdErr != nil && cmdErr.TimedOut
+}
+
+// commandError returns the *util.CommandError within Inner, or nil if the
+// error did not come from running the command.
+func (e *TaskError) commandError() *util.CommandError {
+	var cmdErr *util.CommandError
+	if errors.As(e.Inner, &cmdErr) {
+		return cmdErr
+	}
+	return nil
+}
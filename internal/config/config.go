@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/sapcc/swift-health-exporter/internal/util"
+)
+
+// Config is the content of the config file.
+//
+// Example:
+//
+//	max_failures: 4
+//	collectors:
+//	  dispersion:
+//	    enabled: true
+//	    timeout: 1m
+//	    interval: 5m
+//	  recon.diskusage:
+//	    enabled: true
+//	    options:
+//	      raw_capacity_bytes: "1000000000000"
+//	  recon.md5:
+//	    host_timeout: 2s
+//	    executable_path: /opt/swift/bin/swift-recon
+//	executor:
+//	  type: kubectl
+//	  kubectl:
+//	    namespace: swift
+//	    pod: deployment/swift-proxy
+type Config struct {
+	MaxFailures int                         `yaml:"max_failures"`
+	Collectors  map[string]*CollectorConfig `yaml:"-"` // map of collector name to its config
+	// Executor decides where swift-recon and swift-dispersion-report are run.
+	// They are run on the local host by default.
+	Executor util.ExecutorConfig `yaml:"executor"`
+}
+
+// CollectorConfig holds the configuration for a specific collector.
+type CollectorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Timeout for the context that is used while executing the command.
+	Timeout time.Duration `yaml:"timeout"`
+	// HostTimeout is used for the '--timeout' flag of the swift-recon
+	// command. Only used by recon collectors.
+	HostTimeout time.Duration `yaml:"host_timeout"`
+	// Interval is the minimum time between two metric updates.
+	Interval time.Duration `yaml:"interval"`
+	// ExecutablePath is the path to the swift-recon or the
+	// swift-dispersion-report executable. The executable is searched for in
+	// the usual places if empty.
+	ExecutablePath string `yaml:"executable_path"`
+	// Options holds collector-specific options.
+	Options map[string]string `yaml:"options"`
+}
+
+// DefaultInterval is the default value for CollectorConfig.Interval.
+const DefaultInterval = 1 * time.Minute
+
+// collectorOptions contains the options that each collector accepts in
+// CollectorConfig.Options.
+var collectorOptions = map[string][]string{
+	"dispersion":               nil,
+	"recon.diskusage":          {"raw_capacity_bytes"},
+	"recon.driveaudit":         nil,
+	"recon.md5":                nil,
+	"recon.quarantined":        nil,
+	"recon.replication":        nil,
+	"recon.sharding":           nil,
+	"recon.unmounted":          nil,
+	"recon.updater_sweep_time": nil,
+}
+
+// Default returns the configuration that is used if no config file is given.
+func Default() Config {
+	cfg := Config{
+		MaxFailures: 4,
+		Collectors:  make(map[string]*CollectorConfig, len(collectorOptions)),
+	}
+	for name := range collectorOptions {
+		cc := &CollectorConfig{
+			// In large Swift clusters the dispersion-report tool takes time,
+			// therefore we have a higher default timeout value.
+			Timeout:  20 * time.Second,
+			Interval: DefaultInterval,
+		}
+		if IsRecon(name) {
+			cc.Timeout = 4 * time.Second
+			cc.HostTimeout = 1 * time.Second
+		}
+		cfg.Collectors[name] = cc
+	}
+	cfg.Collectors["recon.md5"].Enabled = true
+	return cfg
+}
+
+// IsRecon returns true if the collector with the given name uses swift-recon.
+func IsRecon(collectorName string) bool {
+	return strings.HasPrefix(collectorName, "recon.")
+}
+
+// Load reads the config file at the given path. Values that are not given in
+// the config file are taken from Default().
+//
+// The result is not validated since command-line flags may still override
+// parts of it. Use Validate() afterwards.
+func Load(path string) (Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := parse(buf)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func parse(buf []byte) (Config, error) {
+	// The collector configs are decoded from yaml.Node values below, but
+	// yaml.Node.Decode() does not reject unknown fields. Therefore the file is
+	// decoded strictly into plain values first to report unknown fields (with
+	// the correct line numbers).
+	var strict struct {
+		MaxFailures int                        `yaml:"max_failures"`
+		Collectors  map[string]CollectorConfig `yaml:"collectors"`
+		Executor    util.ExecutorConfig        `yaml:"executor"`
+	}
+	err := decodeStrict(buf, &strict)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	var file struct {
+		MaxFailures *int                 `yaml:"max_failures"`
+		Collectors  map[string]yaml.Node `yaml:"collectors"`
+		Executor    util.ExecutorConfig  `yaml:"executor"`
+	}
+	err = decodeStrict(buf, &file)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if file.MaxFailures != nil {
+		cfg.MaxFailures = *file.MaxFailures
+	}
+	cfg.Executor = file.Executor
+	for name, node := range file.Collectors {
+		cc, exists := cfg.Collectors[name]
+		if !exists {
+			return Config{}, fmt.Errorf("unknown collector: %q", name)
+		}
+		// Decoding into the existing value keeps the defaults for all fields
+		// that are not given in the config file.
+		err := node.Decode(cc)
+		if err != nil {
+			return Config{}, fmt.Errorf("collectors.%s: %w", name, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func decodeStrict(buf []byte, target any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	return dec.Decode(target)
+}
+
+// Overrides holds the values of the command-line flags that were given
+// explicitly. They take precedence over the config file. Nil fields are not
+// applied.
+type Overrides struct {
+	MaxFailures       *int
+	Enabled           map[string]bool // map of collector name to whether it is enabled
+	DispersionTimeout *time.Duration
+	ReconTimeout      *time.Duration
+	ReconHostTimeout  *time.Duration
+}
+
+// Apply applies the overrides to the given configuration.
+func (o Overrides) Apply(cfg *Config) {
+	if o.MaxFailures != nil {
+		cfg.MaxFailures = *o.MaxFailures
+	}
+	for name, enabled := range o.Enabled {
+		if cc, exists := cfg.Collectors[name]; exists {
+			cc.Enabled = enabled
+		}
+	}
+	for name, cc := range cfg.Collectors {
+		switch {
+		case !IsRecon(name) && o.DispersionTimeout != nil:
+			cc.Timeout = *o.DispersionTimeout
+		case IsRecon(name) && o.ReconTimeout != nil:
+			cc.Timeout = *o.ReconTimeout
+		}
+		if IsRecon(name) && o.ReconHostTimeout != nil {
+			cc.HostTimeout = *o.ReconHostTimeout
+		}
+	}
+}
+
+// Validate checks the configuration for errors. It must be called after all
+// overrides have been applied.
+func (cfg Config) Validate() error {
+	var (
+		errs       []error
+		anyEnabled bool
+	)
+	for _, name := range slices.Sorted(maps.Keys(cfg.Collectors)) {
+		cc := cfg.Collectors[name]
+		anyEnabled = anyEnabled || cc.Enabled
+		if cc.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("collectors.%s.timeout must be positive", name))
+		}
+		if cc.Interval <= 0 {
+			errs = append(errs, fmt.Errorf("collectors.%s.interval must be positive", name))
+		}
+		if IsRecon(name) {
+			// swift-recon only accepts whole seconds for its --timeout flag.
+			switch {
+			case cc.HostTimeout < time.Second:
+				errs = append(errs, fmt.Errorf("collectors.%s.host_timeout must be at least 1s", name))
+			case cc.HostTimeout%time.Second != 0:
+				errs = append(errs, fmt.Errorf("collectors.%s.host_timeout must be a whole number of seconds", name))
+			}
+		}
+		for key := range cc.Options {
+			if !slices.Contains(collectorOptions[name], key) {
+				errs = append(errs, fmt.Errorf("collectors.%s.options: unknown option %q", name, key))
+			}
+		}
+	}
+	if !anyEnabled {
+		errs = append(errs, errors.New("no collector enabled"))
+	}
+	if err := cfg.Executor.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("executor: %w", err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+	if cfg.MaxFailures != 4 {
+		t.Errorf("expected max_failures = 4, got %d", cfg.MaxFailures)
+	}
+	if len(cfg.Collectors) != len(collectorOptions) {
+		t.Errorf("expected %d collectors, got %d", len(collectorOptions), len(cfg.Collectors))
+	}
+	for name, cc := range cfg.Collectors {
+		expected := CollectorConfig{Enabled: name == "recon.md5", Timeout: 20 * time.Second, Interval: DefaultInterval}
+		if IsRecon(name) {
+			expected.Timeout = 4 * time.Second
+			expected.HostTimeout = time.Second
+		}
+		if cc.Enabled != expected.Enabled || cc.Timeout != expected.Timeout || cc.HostTimeout != expected.HostTimeout || cc.Interval != expected.Interval {
+			t.Errorf("collector %s: expected %+v, got %+v", name, expected, *cc)
+		}
+	}
+	err := cfg.Validate()
+	if err != nil {
+		t.Errorf("expected default config to be valid, got %s", err.Error())
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := parse([]byte(`
+max_failures: 2
+collectors:
+  dispersion:
+    enabled: true
+    interval: 5m
+  recon.diskusage:
+    enabled: true
+    options:
+      raw_capacity_bytes: "1000"
+  recon.md5:
+    host_timeout: 3s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxFailures != 2 {
+		t.Errorf("expected max_failures = 2, got %d", cfg.MaxFailures)
+	}
+
+	// Fields that are not given keep their defaults.
+	dispersion := cfg.Collectors["dispersion"]
+	if !dispersion.Enabled || dispersion.Interval != 5*time.Minute || dispersion.Timeout != 20*time.Second {
+		t.Errorf("unexpected config for dispersion: %+v", *dispersion)
+	}
+	md5 := cfg.Collectors["recon.md5"]
+	if !md5.Enabled || md5.HostTimeout != 3*time.Second || md5.Timeout != 4*time.Second {
+		t.Errorf("unexpected config for recon.md5: %+v", *md5)
+	}
+	if v := cfg.Collectors["recon.diskusage"].Options["raw_capacity_bytes"]; v != "1000" {
+		t.Errorf("expected raw_capacity_bytes = 1000, got %q", v)
+	}
+	if cfg.Collectors["recon.sharding"].Enabled {
+		t.Error("expected recon.sharding to stay disabled")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		Input         string
+		ExpectedError string
+	}{
+		{"max_failure: 2\n", "field max_failure not found"},
+		{"collectors:\n  recon.foo:\n    enabled: true\n", `unknown collector: "recon.foo"`},
+		{"collectors:\n  dispersion:\n    intervall: 5m\n", "line 3: field intervall not found"},
+		{"collectors:\n  recon.md5:\n    host_timout: 2s\n", "field host_timout not found"},
+		{"collectors:\n  recon.md5:\n    timeout: soon\n", "line 3: cannot unmarshal !!str `soon` into time.Duration"},
+	}
+	for _, tc := range testCases {
+		_, err := parse([]byte(tc.Input))
+		if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+			t.Errorf("expected error containing %q for %q, got %v", tc.ExpectedError, tc.Input, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("collectors:\n  recon.md5:\n    enabeld: false\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(path)
+	if err == nil || !strings.HasPrefix(err.Error(), "could not parse "+path+": ") {
+		t.Errorf("expected parse error for %s, got %v", path, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Modify        func(cfg *Config)
+		ExpectedError string
+	}{
+		{func(cfg *Config) { cfg.Collectors["dispersion"].Timeout = 0 }, "collectors.dispersion.timeout must be positive"},
+		{func(cfg *Config) { cfg.Collectors["recon.md5"].Interval = -time.Second }, "collectors.recon.md5.interval must be positive"},
+		{func(cfg *Config) { cfg.Collectors["recon.md5"].HostTimeout = 500 * time.Millisecond }, "collectors.recon.md5.host_timeout must be at least 1s"},
+		{func(cfg *Config) { cfg.Collectors["recon.md5"].HostTimeout = 1500 * time.Millisecond }, "collectors.recon.md5.host_timeout must be a whole number of seconds"},
+		{func(cfg *Config) { cfg.Collectors["recon.md5"].Options = map[string]string{"foo": "bar"} }, `collectors.recon.md5.options: unknown option "foo"`},
+		{func(cfg *Config) { cfg.Collectors["recon.md5"].Enabled = false }, "no collector enabled"},
+	}
+	for _, tc := range testCases {
+		cfg := Default()
+		tc.Modify(&cfg)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+			t.Errorf("expected error containing %q, got %v", tc.ExpectedError, err)
+		}
+	}
+
+	// The host timeout is not used by the dispersion collector.
+	cfg := Default()
+	cfg.Collectors["dispersion"].HostTimeout = 1500 * time.Millisecond
+	err := cfg.Validate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	cfg, err := parse([]byte(`
+max_failures: 2
+collectors:
+  dispersion:
+    enabled: true
+    timeout: 1m
+  recon.md5:
+    enabled: true
+    timeout: 10s
+    host_timeout: 3s
+  recon.sharding:
+    enabled: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxFailures := 7
+	reconTimeout := 5 * time.Second
+	Overrides{
+		MaxFailures:  &maxFailures,
+		Enabled:      map[string]bool{"recon.md5": false, "recon.unmounted": true},
+		ReconTimeout: &reconTimeout,
+	}.Apply(&cfg)
+
+	// Explicit flags take precedence over the config file...
+	if cfg.MaxFailures != 7 {
+		t.Errorf("expected max_failures = 7, got %d", cfg.MaxFailures)
+	}
+	if cfg.Collectors["recon.md5"].Enabled || !cfg.Collectors["recon.unmounted"].Enabled {
+		t.Error("expected recon.md5 to be disabled and recon.unmounted to be enabled")
+	}
+	for _, name := range []string{"recon.md5", "recon.sharding", "recon.unmounted"} {
+		if timeout := cfg.Collectors[name].Timeout; timeout != reconTimeout {
+			t.Errorf("expected timeout %s for %s, got %s", reconTimeout, name, timeout)
+		}
+	}
+	// ...while the values from the config file are kept for all other flags.
+	if !cfg.Collectors["dispersion"].Enabled || !cfg.Collectors["recon.sharding"].Enabled {
+		t.Error("expected dispersion and recon.sharding to stay enabled")
+	}
+	if timeout := cfg.Collectors["dispersion"].Timeout; timeout != time.Minute {
+		t.Errorf("expected timeout 1m for dispersion, got %s", timeout)
+	}
+	if hostTimeout := cfg.Collectors["recon.md5"].HostTimeout; hostTimeout != 3*time.Second {
+		t.Errorf("expected host timeout 3s for recon.md5, got %s", hostTimeout)
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package probe
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/sapcc/go-bits/httpapi"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/sapcc/swift-health-exporter/internal/util"
+)
+
+// Config is the content of the probe config file.
+//
+// Example:
+//
+//	clusters:
+//	  eu-de-1:
+//	    swift_dir: /etc/swift-eu-de-1
+//	  eu-de-2:
+//	    swift_dir: /etc/swift-eu-de-2
+//	    recon_path: /opt/swift/bin/swift-recon
+//	    dispersion_report_path: /opt/swift/bin/swift-dispersion-report
+//	  eu-de-3:
+//	    executor:
+//	      type: ssh
+//	      ssh:
+//	        host: swift-jump.eu-de-3.example.com
+//	        identity_file: /etc/swift-health-exporter/id_ed25519
+type Config struct {
+	Clusters map[string]Cluster `yaml:"clusters"`
+}
+
+// Cluster holds the configuration for a Swift cluster that can be probed
+// through the /probe endpoint.
+type Cluster struct {
+	// SwiftDir is the Swift config directory of this cluster. swift-recon
+	// reads the rings from this directory. Optional, defaults to /etc/swift.
+	SwiftDir string `yaml:"swift_dir"`
+	// DispersionConfigFile is the config file for swift-dispersion-report.
+	// Optional, defaults to <swift_dir>/dispersion.conf if SwiftDir is set.
+	DispersionConfigFile string `yaml:"dispersion_config_file"`
+	// ReconPath and DispersionReportPath are optional. The executables are
+	// searched in the same way as for the /metrics endpoint if empty.
+	ReconPath            string `yaml:"recon_path"`
+	DispersionReportPath string `yaml:"dispersion_report_path"`
+	// Executor overrides the executor from the main config file for this
+	// cluster (optional).
+	Executor *util.ExecutorConfig `yaml:"executor"`
+}
+
+// LoadConfig reads the probe config file at the given path.
+func LoadConfig(path string) (Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	err = dec.Decode(&cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if len(cfg.Clusters) == 0 {
+		return Config{}, fmt.Errorf("could not parse %s: no clusters defined", path)
+	}
+	for name, cluster := range cfg.Clusters {
+		if cluster.Executor == nil {
+			continue
+		}
+		err := cluster.Executor.Validate()
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid executor for cluster %q in %s: %w", name, path, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// API implements the httpapi.API interface for the /probe endpoint.
+//
+// Every target has its own metrics handler, which is backed by an isolated
+// Collector/Scraper pair and registry.
+type API struct {
+	mu      sync.RWMutex
+	targets map[string]http.Handler // map of cluster name to its metrics handler
+}
+
+// SetTargets replaces the set of targets that can be probed.
+func (a *API) SetTargets(targets map[string]http.Handler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.targets = targets
+}
+
+// AddTo implements the httpapi.API interface.
+func (a *API) AddTo(r *mux.Router) {
+	r.Methods("GET", "HEAD").Path("/probe").HandlerFunc(a.handleProbe)
+}
+
+func (a *API) handleProbe(w http.ResponseWriter, r *http.Request) {
+	httpapi.IdentifyEndpoint(r, "/probe")
+
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "missing target parameter", http.StatusBadRequest)
+		return
+	}
+	a.mu.RLock()
+	handler, exists := a.targets[target]
+	a.mu.RUnlock()
+	if !exists {
+		http.Error(w, fmt.Sprintf("unknown target: %q", target), http.StatusNotFound)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
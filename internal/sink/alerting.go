@@ -0,0 +1,320 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Default values for the optional fields of Alerter.
+const (
+	defaultAlertRepeatInterval = 4 * time.Hour
+	defaultAlertTimeout        = 10 * time.Second
+)
+
+// AlertRule fires one alert for every series of the given metrics whose value
+// is above the threshold.
+type AlertRule struct {
+	Name      string // value of the "alertname" label
+	Metrics   []string
+	Threshold float64
+	Severity  string
+	// Summary is used for the "summary" annotation. It can refer to label
+	// values with "{label}" and to the metric value with "{value}".
+	Summary string
+}
+
+// DefaultAlertRules returns the built-in alert rules.
+func DefaultAlertRules(replicationAgeThreshold time.Duration) []AlertRule {
+	return []AlertRule{
+		{
+			Name:     "SwiftRingMD5Mismatch",
+			Metrics:  []string{"swift_cluster_md5_not_matched"},
+			Severity: "warning",
+			Summary:  "{kind} md5sum on {storage_ip} does not match the one on disk",
+		},
+		{
+			Name:     "SwiftDrivesUnmounted",
+			Metrics:  []string{"swift_cluster_drives_unmounted"},
+			Severity: "warning",
+			Summary:  "{value} drive(s) unmounted on {storage_ip}",
+		},
+		{
+			Name:     "SwiftDriveAuditErrors",
+			Metrics:  []string{"swift_cluster_drives_audit_errors"},
+			Severity: "info",
+			Summary:  "{value} drive audit error(s) on {storage_ip}",
+		},
+		{
+			Name:     "SwiftDispersionCopiesMissing",
+			Metrics:  []string{"swift_dispersion_container_copies_missing", "swift_dispersion_object_copies_missing"},
+			Severity: "critical",
+			Summary:  "{value} copies missing as reported by swift-dispersion-report",
+		},
+		{
+			Name:      "SwiftReplicationAgeHigh",
+			Metrics:   []string{"swift_cluster_accounts_replication_age", "swift_cluster_containers_replication_age", "swift_cluster_objects_replication_age"},
+			Threshold: replicationAgeThreshold.Seconds(),
+			Severity:  "warning",
+			Summary:   "last replication on {storage_ip} finished {value} seconds ago",
+		},
+		{
+			Name:     "SwiftReconHostErrors",
+			Metrics:  []string{"swift_recon_host_errors"},
+			Severity: "info",
+			Summary:  "{task} failed for {storage_ip}: {reason}",
+		},
+		{
+			Name:     "SwiftHealthCollectorFailing",
+			Metrics:  []string{"swift_dispersion_task_exit_code", "swift_recon_task_exit_code"},
+			Severity: "info",
+			Summary:  "query {query} is failing",
+		},
+	}
+}
+
+// Alerter is a Sink that evaluates alert rules against the metric values and
+// posts the alerts to a webhook in the format that Alertmanager uses for its
+// webhook receivers. This works without Prometheus and Alertmanager.
+//
+// Notifications are only sent when an alert starts firing or is resolved, and
+// then every RepeatInterval while it is firing. Notifications that could not be
+// delivered are retried on the next push.
+type Alerter struct {
+	WebhookURL     string
+	Rules          []AlertRule
+	ExternalLabels map[string]string // optional, added to all alerts
+	RepeatInterval time.Duration     // optional
+	// Timeout limits the duration of a webhook request (optional). The alert
+	// state is locked during the request, so it must not take indefinitely.
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	alerts map[string]*alertState // key = fingerprint
+}
+
+type alertState struct {
+	Labels         map[string]string
+	Annotations    map[string]string
+	StartsAt       time.Time
+	EndsAt         time.Time // zero while firing
+	LastNotifiedAt time.Time // zero if a notification is pending
+}
+
+// Name implements the Sink interface.
+func (a *Alerter) Name() string {
+	return "alerting"
+}
+
+// Push implements the Sink interface.
+func (a *Alerter) Push(ctx context.Context, families []*dto.MetricFamily) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.alerts == nil {
+		a.alerts = make(map[string]*alertState)
+	}
+
+	now := time.Now()
+	firing := a.evaluate(families)
+
+	// Update the state of all alerts.
+	for fp, alert := range firing {
+		state, exists := a.alerts[fp]
+		if !exists || !state.EndsAt.IsZero() {
+			state = &alertState{Labels: alert.Labels, StartsAt: now}
+			a.alerts[fp] = state
+		}
+		state.Annotations = alert.Annotations
+	}
+	for fp, state := range a.alerts {
+		if _, exists := firing[fp]; !exists && state.EndsAt.IsZero() {
+			state.EndsAt = now
+			state.LastNotifiedAt = time.Time{}
+		}
+	}
+
+	// Deduplicate notifications.
+	repeatInterval := a.RepeatInterval
+	if repeatInterval <= 0 {
+		repeatInterval = defaultAlertRepeatInterval
+	}
+	var pending []string
+	for _, fp := range slices.Sorted(maps.Keys(a.alerts)) {
+		state := a.alerts[fp]
+		isFiring := state.EndsAt.IsZero()
+		if state.LastNotifiedAt.IsZero() || (isFiring && now.Sub(state.LastNotifiedAt) >= repeatInterval) {
+			pending = append(pending, fp)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := a.notify(ctx, pending)
+	if err != nil {
+		return err
+	}
+	for _, fp := range pending {
+		state := a.alerts[fp]
+		state.LastNotifiedAt = now
+		if !state.EndsAt.IsZero() {
+			delete(a.alerts, fp)
+		}
+	}
+	return nil
+}
+
+// evaluate returns the alerts that are currently firing.
+func (a *Alerter) evaluate(families []*dto.MetricFamily) map[string]alertState {
+	rulesByMetric := make(map[string][]AlertRule)
+	for _, rule := range a.Rules {
+		for _, metric := range rule.Metrics {
+			rulesByMetric[metric] = append(rulesByMetric[metric], rule)
+		}
+	}
+
+	result := make(map[string]alertState)
+	for _, mf := range families {
+		for _, rule := range rulesByMetric[mf.GetName()] {
+			for _, m := range mf.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if value <= rule.Threshold {
+					continue
+				}
+
+				labels := maps.Clone(a.ExternalLabels)
+				if labels == nil {
+					labels = make(map[string]string)
+				}
+				for _, lp := range m.GetLabel() {
+					labels[lp.GetName()] = lp.GetValue()
+				}
+				labels["alertname"] = rule.Name
+				labels["metric"] = mf.GetName()
+				if rule.Severity != "" {
+					labels["severity"] = rule.Severity
+				}
+
+				valueStr := strconv.FormatFloat(value, 'f', -1, 64)
+				replacements := []string{"{value}", valueStr}
+				for name, value := range labels {
+					replacements = append(replacements, "{"+name+"}", value)
+				}
+				result[alertFingerprint(labels)] = alertState{
+					Labels: labels,
+					Annotations: map[string]string{
+						"summary": strings.NewReplacer(replacements...).Replace(rule.Summary),
+						"value":   valueStr,
+					},
+				}
+			}
+		}
+	}
+	return result
+}
+
+func alertFingerprint(labels map[string]string) string {
+	h := fnv.New64a()
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		h.Write([]byte(name))
+		h.Write([]byte{0xff})
+		h.Write([]byte(labels[name]))
+		h.Write([]byte{0xff})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// The following types are the JSON payload of the Alertmanager webhook
+// receiver (version 4).
+
+type webhookMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []webhookAlert    `json:"alerts"`
+}
+
+type webhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+func (a *Alerter) notify(ctx context.Context, fingerprints []string) error {
+	msg := webhookMessage{
+		Version:           "4",
+		GroupKey:          "{}:{}",
+		Status:            "resolved",
+		Receiver:          "swift-health-exporter",
+		GroupLabels:       map[string]string{},
+		CommonLabels:      map[string]string{},
+		CommonAnnotations: map[string]string{},
+	}
+	for _, fp := range fingerprints {
+		state := a.alerts[fp]
+		status := "resolved"
+		if state.EndsAt.IsZero() {
+			status = "firing"
+			msg.Status = "firing"
+		}
+		msg.Alerts = append(msg.Alerts, webhookAlert{
+			Status:      status,
+			Labels:      state.Labels,
+			Annotations: state.Annotations,
+			StartsAt:    state.StartsAt,
+			EndsAt:      state.EndsAt,
+			Fingerprint: fp,
+		})
+	}
+
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	timeout := a.Timeout
+	if timeout <= 0 {
+		timeout = defaultAlertTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.WebhookURL, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck // only used for the error message
+		return fmt.Errorf("%s returned %s: %s", a.WebhookURL, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
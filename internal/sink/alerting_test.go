@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sapcc/go-bits/must"
+)
+
+// alertingTestServer records the notifications that it receives. It responds
+// with the given status code, or not at all if the status code is zero.
+type alertingTestServer struct {
+	*httptest.Server
+	statusCode    int
+	notifications []webhookMessage
+}
+
+func newAlertingTestServer(t *testing.T) *alertingTestServer {
+	s := &alertingTestServer{statusCode: http.StatusOK}
+	release := make(chan struct{})
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg webhookMessage
+		must.SucceedT(t, json.NewDecoder(r.Body).Decode(&msg))
+		if s.statusCode == 0 {
+			<-release
+			return
+		}
+		s.notifications = append(s.notifications, msg)
+		w.WriteHeader(s.statusCode)
+	}))
+	t.Cleanup(func() {
+		close(release)
+		s.Close()
+	})
+	return s
+}
+
+// alertsByName returns the alerts in the given notification by their
+// "alertname" label.
+func alertsByName(msg webhookMessage) map[string]webhookAlert {
+	result := make(map[string]webhookAlert, len(msg.Alerts))
+	for _, alert := range msg.Alerts {
+		result[alert.Labels["alertname"]] = alert
+	}
+	return result
+}
+
+func TestAlerter(t *testing.T) {
+	server := newAlertingTestServer(t)
+	alerter := &Alerter{
+		WebhookURL:     server.URL,
+		Rules:          DefaultAlertRules(time.Hour),
+		ExternalLabels: map[string]string{"cluster": "eu-de-1"},
+	}
+
+	// testFamilies() has one unmounted drive on 10.0.0.2 and one md5 mismatch
+	// on 10.0.0.1. The counter does not match any rule.
+	must.SucceedT(t, alerter.Push(t.Context(), testFamilies()))
+	if len(server.notifications) != 1 || server.notifications[0].Status != "firing" {
+		t.Fatalf("expected one firing notification, got %+v", server.notifications)
+	}
+	alerts := alertsByName(server.notifications[0])
+	if len(alerts) != 2 {
+		t.Errorf("expected 2 alerts, got %+v", server.notifications[0].Alerts)
+	}
+	for name, expected := range map[string]webhookAlert{
+		"SwiftDrivesUnmounted": {
+			Labels: map[string]string{
+				"alertname":  "SwiftDrivesUnmounted",
+				"cluster":    "eu-de-1",
+				"metric":     "swift_cluster_drives_unmounted",
+				"severity":   "warning",
+				"storage_ip": "10.0.0.2",
+			},
+			Annotations: map[string]string{"summary": "1 drive(s) unmounted on 10.0.0.2", "value": "1"},
+		},
+		"SwiftRingMD5Mismatch": {
+			Labels: map[string]string{
+				"alertname":  "SwiftRingMD5Mismatch",
+				"cluster":    "eu-de-1",
+				"kind":       "ring",
+				"metric":     "swift_cluster_md5_not_matched",
+				"severity":   "warning",
+				"storage_ip": "10.0.0.1",
+			},
+			Annotations: map[string]string{"summary": "ring md5sum on 10.0.0.1 does not match the one on disk", "value": "2"},
+		},
+	} {
+		alert := alerts[name]
+		if alert.Status != "firing" || !maps.Equal(alert.Labels, expected.Labels) || !maps.Equal(alert.Annotations, expected.Annotations) {
+			t.Errorf("expected alert %s with labels %v and annotations %v, got %+v", name, expected.Labels, expected.Annotations, alert)
+		}
+		if alert.StartsAt.IsZero() || !alert.EndsAt.IsZero() {
+			t.Errorf("expected alert %s to have started but not ended, got %+v", name, alert)
+		}
+	}
+
+	// Unchanged alerts are not sent again.
+	must.SucceedT(t, alerter.Push(t.Context(), testFamilies()))
+	if len(server.notifications) != 1 {
+		t.Fatalf("expected no further notification, got %+v", server.notifications[1:])
+	}
+
+	// When a metric value goes back to normal, only that alert is resolved.
+	families := testFamilies()
+	families[1].Metric[0].Gauge.Value = new(float64)
+	must.SucceedT(t, alerter.Push(t.Context(), families))
+	if len(server.notifications) != 2 || server.notifications[1].Status != "resolved" {
+		t.Fatalf("expected a resolved notification, got %+v", server.notifications[1:])
+	}
+	alerts = alertsByName(server.notifications[1])
+	resolved, exists := alerts["SwiftRingMD5Mismatch"]
+	if len(alerts) != 1 || !exists || resolved.Status != "resolved" || resolved.EndsAt.IsZero() {
+		t.Errorf("expected only SwiftRingMD5Mismatch to be resolved, got %+v", server.notifications[1].Alerts)
+	}
+
+	// Notifications that could not be delivered are retried on the next push.
+	server.statusCode = http.StatusInternalServerError
+	if err := alerter.Push(t.Context(), nil); err == nil {
+		t.Error("expected push to fail when the webhook returns an error")
+	}
+	server.statusCode = http.StatusOK
+	must.SucceedT(t, alerter.Push(t.Context(), nil))
+	if len(server.notifications) != 4 || server.notifications[3].Status != "resolved" {
+		t.Fatalf("expected the resolved notification to be sent again, got %+v", server.notifications[2:])
+	}
+	if alerts := alertsByName(server.notifications[3]); len(alerts) != 1 || alerts["SwiftDrivesUnmounted"].Status != "resolved" {
+		t.Errorf("expected SwiftDrivesUnmounted to be resolved, got %+v", server.notifications[3].Alerts)
+	}
+	if len(alerter.alerts) != 0 {
+		t.Errorf("expected resolved alerts to be forgotten after the notification, got %v", alerter.alerts)
+	}
+}
+
+func TestAlerterTimeout(t *testing.T) {
+	server := newAlertingTestServer(t)
+	server.statusCode = 0
+	alerter := &Alerter{
+		WebhookURL: server.URL,
+		Rules:      DefaultAlertRules(time.Hour),
+		Timeout:    10 * time.Millisecond,
+	}
+
+	// A webhook that does not respond does not block the alerter...
+	startedAt := time.Now()
+	err := alerter.Push(t.Context(), testFamilies())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+	if duration := time.Since(startedAt); duration > 5*time.Second {
+		t.Errorf("expected push to be aborted after the timeout, but it took %s", duration)
+	}
+
+	// ...and the notification is sent once the webhook responds again.
+	server.statusCode = http.StatusOK
+	must.SucceedT(t, alerter.Push(t.Context(), testFamilies()))
+	if len(server.notifications) != 1 || server.notifications[0].Status != "firing" || len(server.notifications[0].Alerts) != 2 {
+		t.Errorf("expected one firing notification with 2 alerts, got %+v", server.notifications)
+	}
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sapcc/go-api-declarations/bininfo"
+)
+
+// OTLP is a Sink that sends the metric values to an OpenTelemetry collector
+// using OTLP/HTTP with JSON encoding. Gauges (and untyped metrics) are sent as
+// OTLP gauges, counters as cumulative monotonic sums. Histograms and summaries
+// (which only occur in the Go runtime metrics) are skipped.
+type OTLP struct {
+	// Endpoint is the full URL of the metrics endpoint of the collector, e.g.
+	// "http://otel-collector:4318/v1/metrics".
+	Endpoint string
+	// ResourceAttributes are added to the resource attributes that are derived
+	// from bininfo, e.g. {"cluster": "eu-de-1"}.
+	ResourceAttributes map[string]string
+}
+
+// Name implements the Sink interface.
+func (o OTLP) Name() string {
+	return "otlp"
+}
+
+// Push implements the Sink interface.
+func (o OTLP) Push(ctx context.Context, families []*dto.MetricFamily) error {
+	buf, err := json.Marshal(o.request(families, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.Endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck // only used for the error message
+		return fmt.Errorf("%s returned %s: %s", o.Endpoint, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// The following types are the JSON encoding of the OTLP
+// ExportMetricsServiceRequest message. Only the fields that we need are
+// included.
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+// AGGREGATION_TEMPORALITY_CUMULATIVE
+const otlpAggregationTemporalityCumulative = 2
+
+type otlpDataPoint struct {
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	TimeUnixNano string          `json:"timeUnixNano"` // uint64 values are encoded as strings
+	AsDouble     float64         `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func (o OTLP) request(families []*dto.MetricFamily, now time.Time) otlpRequest {
+	component := cmp.Or(bininfo.Component(), "swift-health-exporter")
+	attrs := map[string]string{
+		"service.name":    component,
+		"service.version": bininfo.VersionOr("unknown"),
+	}
+	if commit := bininfo.Commit(); commit != "" {
+		attrs["vcs.revision"] = commit
+	}
+	maps.Copy(attrs, o.ResourceAttributes)
+
+	var metrics []otlpMetric
+	for _, mf := range families {
+		m := otlpMetric{Name: mf.GetName(), Description: mf.GetHelp()}
+		dataPoints := make([]otlpDataPoint, 0, len(mf.GetMetric()))
+		for _, metric := range mf.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel()))
+			for _, lp := range metric.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			ts := now
+			if metric.TimestampMs != nil {
+				ts = time.UnixMilli(metric.GetTimestampMs())
+			}
+
+			dp := otlpDataPoint{
+				Attributes:   otlpAttributes(labels),
+				TimeUnixNano: strconv.FormatInt(ts.UnixNano(), 10),
+			}
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				dp.AsDouble = metric.GetGauge().GetValue()
+			case dto.MetricType_UNTYPED:
+				dp.AsDouble = metric.GetUntyped().GetValue()
+			case dto.MetricType_COUNTER:
+				dp.AsDouble = metric.GetCounter().GetValue()
+			default:
+				continue
+			}
+			dataPoints = append(dataPoints, dp)
+		}
+		if len(dataPoints) == 0 {
+			continue
+		}
+
+		if mf.GetType() == dto.MetricType_COUNTER {
+			m.Sum = &otlpSum{
+				DataPoints:             dataPoints,
+				AggregationTemporality: otlpAggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+		} else {
+			m.Gauge = &otlpGauge{DataPoints: dataPoints}
+		}
+		metrics = append(metrics, m)
+	}
+
+	return otlpRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResource{Attributes: otlpAttributes(attrs)},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: component, Version: bininfo.Version()},
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs map[string]string) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attrs))
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		result = append(result, otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: attrs[key]}})
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sapcc/go-bits/must"
+)
+
+// The following types decode the OTLP JSON payload independently of the
+// types in otlp.go, so that the tests check the actual encoding.
+
+type otlpTestRequest struct {
+	ResourceMetrics []struct {
+		Resource struct {
+			Attributes otlpTestAttributes `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			Metrics []otlpTestMetric `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type otlpTestMetric struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Gauge       *struct {
+		DataPoints []otlpTestDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+	Sum *struct {
+		DataPoints             []otlpTestDataPoint `json:"dataPoints"`
+		AggregationTemporality int                 `json:"aggregationTemporality"`
+		IsMonotonic            bool                `json:"isMonotonic"`
+	} `json:"sum"`
+}
+
+type otlpTestDataPoint struct {
+	Attributes   otlpTestAttributes `json:"attributes"`
+	TimeUnixNano string             `json:"timeUnixNano"`
+	AsDouble     float64            `json:"asDouble"`
+}
+
+// otlpTestAttributes decodes a list of OTLP attributes into a map, so that
+// the tests do not depend on their order.
+type otlpTestAttributes map[string]string
+
+func (a *otlpTestAttributes) UnmarshalJSON(buf []byte) error {
+	var attrs []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+	err := json.Unmarshal(buf, &attrs)
+	if err != nil {
+		return err
+	}
+	*a = make(otlpTestAttributes, len(attrs))
+	for _, attr := range attrs {
+		(*a)[attr.Key] = attr.Value.StringValue
+	}
+	return nil
+}
+
+func TestOTLPRequest(t *testing.T) {
+	o := OTLP{
+		Endpoint:           "http://otel-collector:4318/v1/metrics",
+		ResourceAttributes: map[string]string{"cluster": "eu-de-1", "service.name": "swift-health-exporter-eu-de-1"},
+	}
+	var req otlpTestRequest
+	buf := must.ReturnT(json.Marshal(o.request(testFamilies(), testNow)))(t)
+	must.SucceedT(t, json.Unmarshal(buf, &req))
+
+	if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("expected one resource and one scope, got %s", buf)
+	}
+	rm := req.ResourceMetrics[0]
+
+	// The given resource attributes are added to (and override) the ones
+	// derived from bininfo.
+	attrs := rm.Resource.Attributes
+	if attrs["cluster"] != "eu-de-1" || attrs["service.name"] != "swift-health-exporter-eu-de-1" || attrs["service.version"] == "" {
+		t.Errorf("unexpected resource attributes: %v", attrs)
+	}
+
+	// The summary is skipped.
+	metrics := make(map[string]otlpTestMetric)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	if len(metrics) != 3 {
+		t.Errorf("expected 3 metrics, got %s", buf)
+	}
+	if _, exists := metrics["go_gc_duration_seconds"]; exists {
+		t.Error("expected summaries to be skipped")
+	}
+
+	// Gauges are sent as OTLP gauges with the labels as attributes.
+	unmounted := metrics["swift_cluster_drives_unmounted"]
+	if unmounted.Gauge == nil || unmounted.Sum != nil || unmounted.Description != "Unmounted drives reported by the swift-recon tool." {
+		t.Fatalf("expected swift_cluster_drives_unmounted to be a gauge with description, got %+v", unmounted)
+	}
+	nowNano := strconv.FormatInt(testNow.UnixNano(), 10)
+	for idx, expected := range []otlpTestDataPoint{
+		{Attributes: otlpTestAttributes{"storage_ip": "10.0.0.1"}, TimeUnixNano: nowNano, AsDouble: 0},
+		{Attributes: otlpTestAttributes{"storage_ip": "10.0.0.2"}, TimeUnixNano: nowNano, AsDouble: 1},
+	} {
+		if idx >= len(unmounted.Gauge.DataPoints) {
+			t.Errorf("missing data point %+v", expected)
+			continue
+		}
+		dp := unmounted.Gauge.DataPoints[idx]
+		if dp.Attributes["storage_ip"] != expected.Attributes["storage_ip"] || len(dp.Attributes) != 1 ||
+			dp.TimeUnixNano != expected.TimeUnixNano || dp.AsDouble != expected.AsDouble {
+			t.Errorf("expected data point %+v, got %+v", expected, dp)
+		}
+	}
+
+	// Restored metric values keep their original timestamp.
+	md5 := metrics["swift_cluster_md5_not_matched"]
+	if md5.Gauge == nil || len(md5.Gauge.DataPoints) != 1 {
+		t.Fatalf("expected one data point for swift_cluster_md5_not_matched, got %+v", md5)
+	}
+	dp := md5.Gauge.DataPoints[0]
+	if expected := strconv.FormatInt(testNow.Add(-time.Hour).UnixNano(), 10); dp.TimeUnixNano != expected {
+		t.Errorf("expected timestamp %s for restored value, got %s", expected, dp.TimeUnixNano)
+	}
+	if dp.Attributes["kind"] != "ring" || dp.Attributes["storage_ip"] != "10.0.0.1" || dp.AsDouble != 2 {
+		t.Errorf("unexpected data point for swift_cluster_md5_not_matched: %+v", dp)
+	}
+
+	// Counters are sent as cumulative monotonic sums.
+	failures := metrics["swift_health_exporter_push_failures_total"]
+	if failures.Sum == nil || failures.Gauge != nil || !failures.Sum.IsMonotonic || failures.Sum.AggregationTemporality != 2 {
+		t.Fatalf("expected a cumulative monotonic sum, got %+v", failures)
+	}
+	if len(failures.Sum.DataPoints) != 1 || failures.Sum.DataPoints[0].AsDouble != 3 || failures.Sum.DataPoints[0].Attributes["sink"] != "statsd" {
+		t.Errorf("unexpected data points for counter: %+v", failures.Sum.DataPoints)
+	}
+}
+
+func TestOTLPPush(t *testing.T) {
+	var received []otlpTestRequest
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var req otlpTestRequest
+		must.SucceedT(t, json.NewDecoder(r.Body).Decode(&req))
+		received = append(received, req)
+		http.Error(w, http.StatusText(statusCode), statusCode)
+	}))
+	defer server.Close()
+
+	o := OTLP{Endpoint: server.URL + "/v1/metrics"}
+	must.SucceedT(t, o.Push(t.Context(), testFamilies()))
+	if len(received) != 1 || len(received[0].ResourceMetrics) != 1 {
+		t.Errorf("expected one request with one resource, got %+v", received)
+	}
+
+	// Error responses are reported with the response body.
+	statusCode = http.StatusBadGateway
+	err := o.Push(t.Context(), testFamilies())
+	if expected := server.URL + "/v1/metrics returned 502 Bad Gateway: Bad Gateway"; err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Pushgateway is a Sink that pushes the metric values to a Prometheus
+// Pushgateway. All metrics of the job and grouping labels are replaced with
+// every push.
+type Pushgateway struct {
+	URL      string
+	Job      string
+	Grouping map[string]string // optional, e.g. {"cluster": "eu-de-1", "region": "eu-de"}
+}
+
+// Name implements the Sink interface.
+func (p Pushgateway) Name() string {
+	return "pushgateway"
+}
+
+// Push implements the Sink interface.
+func (p Pushgateway) Push(ctx context.Context, families []*dto.MetricFamily) error {
+	// The Pushgateway rejects samples with explicit timestamps, which we have
+	// for metric values that were restored from the state file.
+	families = withoutTimestamps(families)
+
+	pusher := push.New(p.URL, p.Job).Client(httpClient).Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return families, nil
+	}))
+	for name, value := range p.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	return pusher.PushContext(ctx)
+}
+
+func withoutTimestamps(families []*dto.MetricFamily) []*dto.MetricFamily {
+	result := make([]*dto.MetricFamily, len(families))
+	for idx, mf := range families {
+		mf = proto.CloneOf(mf)
+		for _, m := range mf.GetMetric() {
+			m.TimestampMs = nil
+		}
+		result[idx] = mf
+	}
+	return result
+}
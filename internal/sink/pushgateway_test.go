@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestPushgateway(t *testing.T) {
+	var (
+		requests []string
+		received = make(map[string]*dto.MetricFamily)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			var mf dto.MetricFamily
+			err := dec.Decode(&mf)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Error(err)
+				break
+			}
+			received[mf.GetName()] = &mf
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	families := testFamilies()
+	err := Pushgateway{
+		URL:      server.URL,
+		Job:      "swift-health-exporter",
+		Grouping: map[string]string{"cluster": "eu-de-1"},
+	}.Push(t.Context(), families)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All metrics of the job and grouping labels are replaced with PUT.
+	if len(requests) != 1 || requests[0] != "PUT /metrics/job/swift-health-exporter/cluster/eu-de-1" {
+		t.Errorf("expected one PUT request for the job and grouping labels, got %v", requests)
+	}
+	if len(received) != len(families) {
+		t.Errorf("expected %d metric families, got %d", len(families), len(received))
+	}
+
+	unmounted := received["swift_cluster_drives_unmounted"]
+	if len(unmounted.GetMetric()) != 2 || unmounted.GetMetric()[1].GetGauge().GetValue() != 1 {
+		t.Errorf("unexpected values for swift_cluster_drives_unmounted: %v", unmounted)
+	}
+
+	// Timestamps of restored metric values are removed because the
+	// Pushgateway rejects them, but only in the pushed copy.
+	md5 := received["swift_cluster_md5_not_matched"]
+	if len(md5.GetMetric()) != 1 || md5.GetMetric()[0].TimestampMs != nil {
+		t.Errorf("expected swift_cluster_md5_not_matched without timestamp, got %v", md5)
+	}
+	if families[1].GetMetric()[0].TimestampMs == nil {
+		t.Error("expected the timestamp in the original metric family to be kept")
+	}
+}
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sapcc/go-bits/logg"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Default values for the optional fields of RemoteWrite.
+const (
+	defaultRemoteWriteMaxBufferAge = 15 * time.Minute
+	defaultRemoteWriteTimeout      = 10 * time.Second
+	defaultRemoteWriteMinBackoff   = 10 * time.Second
+	defaultRemoteWriteMaxBackoff   = 5 * time.Minute
+)
+
+// RemoteWrite is a Sink that writes the metric values to a Prometheus
+// remote-write endpoint (e.g. Thanos receive). The samples from each update
+// cycle are sent as one batch. Batches that could not be sent are kept in
+// memory and retried on a later push until they are older than MaxBufferAge.
+type RemoteWrite struct {
+	URL            string
+	ExternalLabels map[string]string // optional, added to all series
+	MaxBufferAge   time.Duration     // optional
+	// Timeout limits the total time that a single push spends on sending the
+	// buffered batches (optional).
+	Timeout time.Duration
+	// After a failed push, sending is paused for MinBackoff, and the batches
+	// are only buffered until then. The backoff is doubled after each
+	// consecutive failure up to MaxBackoff (both optional).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	mu          sync.Mutex
+	buffer      []remoteWriteBatch // oldest first
+	backoff     time.Duration      // zero if the last push succeeded
+	pausedUntil time.Time
+	now         func() time.Time // for tests; time.Now if nil
+}
+
+type remoteWriteBatch struct {
+	createdAt time.Time
+	payload   []byte // snappy-compressed WriteRequest
+}
+
+// remoteWriteError is returned for responses that must not be retried.
+type remoteWriteError struct {
+	error
+}
+
+// Name implements the Sink interface.
+func (r *RemoteWrite) Name() string {
+	return "remote-write"
+}
+
+// Push implements the Sink interface.
+func (r *RemoteWrite) Push(ctx context.Context, families []*dto.MetricFamily) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	r.buffer = append(r.buffer, remoteWriteBatch{
+		createdAt: now,
+		payload:   snappy.Encode(nil, encodeWriteRequest(families, r.ExternalLabels, now)),
+	})
+
+	// Drop batches that have been buffered for too long.
+	maxBufferAge := r.MaxBufferAge
+	if maxBufferAge <= 0 {
+		maxBufferAge = defaultRemoteWriteMaxBufferAge
+	}
+	dropCount := 0
+	for dropCount < len(r.buffer) && now.Sub(r.buffer[dropCount].createdAt) > maxBufferAge {
+		dropCount++
+	}
+	if dropCount > 0 {
+		logg.Error("dropping %d batches for %s that could not be sent within %s", dropCount, r.URL, maxBufferAge)
+		r.buffer = r.buffer[dropCount:]
+	}
+
+	if now.Before(r.pausedUntil) {
+		logg.Debug("not sending %d batches to %s until %s because of previous failures", len(r.buffer), r.URL, r.pausedUntil.Format(time.RFC3339))
+		return nil
+	}
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultRemoteWriteTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Send the buffered batches in order. On the first failure, the remaining
+	// batches stay in the buffer for a later push.
+	for len(r.buffer) > 0 {
+		err := r.send(ctx, r.buffer[0].payload)
+		var rwErr remoteWriteError
+		if errors.As(err, &rwErr) {
+			// Retrying will not help, so drop the batch.
+			r.buffer = r.buffer[1:]
+			return err
+		}
+		if err != nil {
+			r.pause(now)
+			return fmt.Errorf("%w (%d batches buffered, next attempt after %s)", err, len(r.buffer), r.pausedUntil.Format(time.RFC3339))
+		}
+		r.buffer = r.buffer[1:]
+	}
+	r.backoff = 0
+	return nil
+}
+
+// pause doubles the backoff and pauses sending for that long.
+func (r *RemoteWrite) pause(now time.Time) {
+	minBackoff := r.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultRemoteWriteMinBackoff
+	}
+	maxBackoff := r.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRemoteWriteMaxBackoff
+	}
+	r.backoff = min(max(2*r.backoff, minBackoff), maxBackoff)
+	r.pausedUntil = now.Add(r.backoff)
+}
+
+func (r *RemoteWrite) send(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(payload))
+	if err != nil {
+		return remoteWriteError{err}
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck // only used for the error message
+	err = fmt.Errorf("%s returned %s: %s", r.URL, resp.Status, bytes.TrimSpace(body))
+	// As per the remote-write spec, only 5xx and 429 responses may be retried.
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return remoteWriteError{err}
+}
+
+// encodeWriteRequest returns the protobuf encoding of a prometheus.WriteRequest
+// message for the given metric families. Only gauges, counters and untyped
+// metrics are included.
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label        { string name = 1; string value = 2; }
+//	message Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(families []*dto.MetricFamily, externalLabels map[string]string, now time.Time) []byte {
+	var result []byte
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			var value float64
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				value = m.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				value = m.GetCounter().GetValue()
+			case dto.MetricType_UNTYPED:
+				value = m.GetUntyped().GetValue()
+			default:
+				continue
+			}
+			timestamp := now.UnixMilli()
+			if m.TimestampMs != nil {
+				timestamp = m.GetTimestampMs()
+			}
+
+			labels := maps.Clone(externalLabels)
+			if labels == nil {
+				labels = make(map[string]string, len(m.GetLabel())+1)
+			}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			labels["__name__"] = mf.GetName()
+
+			var ts []byte
+			for _, name := range slices.Sorted(maps.Keys(labels)) {
+				var label []byte
+				label = protowire.AppendTag(label, 1, protowire.BytesType)
+				label = protowire.AppendString(label, name)
+				label = protowire.AppendTag(label, 2, protowire.BytesType)
+				label = protowire.AppendString(label, labels[name])
+				ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+				ts = protowire.AppendBytes(ts, label)
+			}
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(timestamp)) //nolint:gosec // int64 fields are encoded as two's complement
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sample)
+
+			result = protowire.AppendTag(result, 1, protowire.BytesType)
+			result = protowire.AppendBytes(result, ts)
+		}
+	}
+	return result
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sapcc/go-bits/must"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// remoteWriteTestSeries is a decoded prometheus.TimeSeries message with a
+// single sample.
+type remoteWriteTestSeries struct {
+	Labels    [][2]string // in the order in which they were encoded
+	Value     float64
+	Timestamp int64
+}
+
+func (s remoteWriteTestSeries) String() string {
+	labels := make([]string, len(s.Labels))
+	for idx, label := range s.Labels {
+		labels[idx] = label[0] + "=" + label[1]
+	}
+	return fmt.Sprintf("{%s} %g @%d", strings.Join(labels, ","), s.Value, s.Timestamp)
+}
+
+// decodeWriteRequest decodes the payload of a remote-write request, see the
+// message definitions on encodeWriteRequest.
+func decodeWriteRequest(t *testing.T, payload []byte) []remoteWriteTestSeries {
+	t.Helper()
+	var result []remoteWriteTestSeries
+	for _, tsField := range decodeTestFields(t, payload) {
+		var ts remoteWriteTestSeries
+		for _, field := range decodeTestFields(t, tsField.bytes) {
+			sub := decodeTestFields(t, field.bytes)
+			if len(sub) != 2 {
+				t.Fatalf("expected two fields in field %d of TimeSeries, got %d", field.number, len(sub))
+			}
+			switch field.number {
+			case 1:
+				ts.Labels = append(ts.Labels, [2]string{string(sub[0].bytes), string(sub[1].bytes)})
+			case 2:
+				ts.Value = math.Float64frombits(sub[0].number64)
+				ts.Timestamp = int64(sub[1].number64) //nolint:gosec // int64 fields are encoded as two's complement
+			}
+		}
+		result = append(result, ts)
+	}
+	return result
+}
+
+type testField struct {
+	number   protowire.Number
+	bytes    []byte // for length-delimited fields
+	number64 uint64 // for varint and fixed64 fields
+}
+
+// decodeTestFields returns all fields of the given protobuf message in order.
+func decodeTestFields(t *testing.T, buf []byte) []testField {
+	t.Helper()
+	var result []testField
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		buf = buf[n:]
+		field := testField{number: num}
+		switch typ {
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(buf)
+		case protowire.VarintType:
+			field.number64, n = protowire.ConsumeVarint(buf)
+		case protowire.Fixed64Type:
+			field.number64, n = protowire.ConsumeFixed64(buf)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		buf = buf[n:]
+		result = append(result, field)
+	}
+	return result
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	// The external labels must not override the labels of the metric.
+	externalLabels := map[string]string{"cluster": "eu-de-1", "storage_ip": "ignored"}
+	series := decodeWriteRequest(t, encodeWriteRequest(testFamilies(), externalLabels, testNow))
+
+	var actual []string
+	for _, ts := range series {
+		actual = append(actual, ts.String())
+		// Labels must be sorted by name, which puts __name__ first.
+		if len(ts.Labels) == 0 || ts.Labels[0][0] != "__name__" {
+			t.Errorf("expected __name__ as the first label in %s", ts)
+		}
+		if !slices.IsSortedFunc(ts.Labels, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) }) {
+			t.Errorf("expected sorted labels in %s", ts)
+		}
+	}
+
+	// The summary is skipped, the counter is included, and restored values
+	// keep their original timestamp.
+	nowMs := testNow.UnixMilli()
+	expected := []string{
+		fmt.Sprintf("{__name__=swift_cluster_drives_unmounted,cluster=eu-de-1,storage_ip=10.0.0.1} 0 @%d", nowMs),
+		fmt.Sprintf("{__name__=swift_cluster_drives_unmounted,cluster=eu-de-1,storage_ip=10.0.0.2} 1 @%d", nowMs),
+		fmt.Sprintf("{__name__=swift_cluster_md5_not_matched,cluster=eu-de-1,kind=ring,storage_ip=10.0.0.1} 2 @%d", testNow.Add(-time.Hour).UnixMilli()),
+		fmt.Sprintf("{__name__=swift_health_exporter_push_failures_total,cluster=eu-de-1,sink=statsd,storage_ip=ignored} 3 @%d", nowMs),
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected series\n\t%s\ngot\n\t%s", strings.Join(expected, "\n\t"), strings.Join(actual, "\n\t"))
+	}
+}
+
+// remoteWriteTestFamilies returns a single gauge with the given value, so that
+// the tests can tell the batches apart.
+func remoteWriteTestFamilies(value float64) []*dto.MetricFamily {
+	return []*dto.MetricFamily{{
+		Name:   proto.String("swift_cluster_drives_unmounted"),
+		Type:   dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{gaugeMetric(value, "storage_ip", "10.0.0.1")},
+	}}
+}
+
+func TestRemoteWrite(t *testing.T) {
+	statusCode := http.StatusServiceUnavailable
+	var received []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			http.Error(w, "unexpected request headers", http.StatusBadRequest)
+			return
+		}
+		if statusCode != http.StatusNoContent {
+			http.Error(w, http.StatusText(statusCode), statusCode)
+			return
+		}
+		body := must.ReturnT(io.ReadAll(r.Body))(t)
+		for _, ts := range decodeWriteRequest(t, must.ReturnT(snappy.Decode(nil, body))(t)) {
+			received = append(received, ts.Value)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	now := testNow
+	rw := &RemoteWrite{
+		URL:          server.URL,
+		MaxBufferAge: 5 * time.Minute,
+		MinBackoff:   time.Minute,
+		MaxBackoff:   3 * time.Minute,
+		now:          func() time.Time { return now },
+	}
+	pushAt := func(offset time.Duration, value float64) error {
+		now = testNow.Add(offset)
+		return rw.Push(t.Context(), remoteWriteTestFamilies(value))
+	}
+
+	// While the receiver is unavailable, the batches are buffered and sending
+	// is paused for MinBackoff...
+	err := pushAt(0, 1)
+	if err == nil || !strings.Contains(err.Error(), "503 Service Unavailable") || !strings.Contains(err.Error(), "1 batches buffered") {
+		t.Errorf("expected push to fail while the receiver is unavailable, got %v", err)
+	}
+	must.SucceedT(t, pushAt(30*time.Second, 2))
+	// ...and for twice as long after each further failure, up to MaxBackoff.
+	for _, step := range []struct {
+		Offset          time.Duration
+		Value           float64
+		IsPaused        bool
+		ExpectedBackoff time.Duration
+	}{
+		{time.Minute, 3, false, 2 * time.Minute},
+		{2 * time.Minute, 4, true, 2 * time.Minute},
+		{3 * time.Minute, 5, false, 3 * time.Minute},
+		{5 * time.Minute, 6, true, 3 * time.Minute},
+	} {
+		err := pushAt(step.Offset, step.Value)
+		if step.IsPaused && err != nil {
+			t.Errorf("expected push at %s to only buffer the batch, got %s", step.Offset, err.Error())
+		}
+		if !step.IsPaused && err == nil {
+			t.Errorf("expected push at %s to fail while the receiver is unavailable", step.Offset)
+		}
+		if rw.backoff != step.ExpectedBackoff {
+			t.Errorf("expected backoff of %s after push at %s, got %s", step.ExpectedBackoff, step.Offset, rw.backoff)
+		}
+	}
+	if len(received) != 0 || len(rw.buffer) != 6 {
+		t.Errorf("expected 6 buffered batches and nothing received, got %d batches and %v", len(rw.buffer), received)
+	}
+
+	// Once the receiver is available again, the buffered batches are sent in
+	// order, except for those older than MaxBufferAge.
+	statusCode = http.StatusNoContent
+	must.SucceedT(t, pushAt(6*time.Minute, 7))
+	if !slices.Equal(received, []float64{3, 4, 5, 6, 7}) {
+		t.Errorf("expected the buffered batches to be sent in order, got %v", received)
+	}
+	if len(rw.buffer) != 0 || rw.backoff != 0 {
+		t.Errorf("expected an empty buffer and no backoff after a successful push, got %d batches and %s", len(rw.buffer), rw.backoff)
+	}
+
+	// Batches that are rejected by the receiver are dropped without pausing.
+	statusCode = http.StatusBadRequest
+	err = pushAt(7*time.Minute, 8)
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request") {
+		t.Errorf("expected push to fail with 400, got %v", err)
+	}
+	if len(rw.buffer) != 0 || rw.backoff != 0 {
+		t.Errorf("expected rejected batch to be dropped without backoff, got %d batches and %s", len(rw.buffer), rw.backoff)
+	}
+}
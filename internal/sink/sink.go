@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sink contains the destinations that the metric values can be pushed
+// to after each update cycle, in addition to being scraped by Prometheus.
+package sink
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sapcc/go-bits/logg"
+)
+
+// Default value for Pusher.Timeout.
+const defaultPushTimeout = 30 * time.Second
+
+// httpClient is used by all Sinks that push via HTTP. The timeout is a
+// safeguard in case a Sink is used without a Pusher, which applies its own
+// (usually shorter) timeout through the context.
+var httpClient = &http.Client{Timeout: 2 * defaultPushTimeout}
+
+// Sink is a destination that metric values are pushed to.
+type Sink interface {
+	Name() string
+	Push(ctx context.Context, families []*dto.MetricFamily) error
+}
+
+// Pusher pushes the metric values from a Gatherer to a set of Sinks.
+type Pusher struct {
+	Gatherer prometheus.Gatherer
+	Sinks    []Sink
+	// Timeout limits the duration of each push to a single Sink (optional).
+	// Since pushing happens between update cycles, a Sink that does not
+	// respond would otherwise stop all metric updates.
+	Timeout time.Duration
+
+	failures *prometheus.CounterVec
+}
+
+// NewPusher returns a new Pusher. The failure metric is registered with the
+// given Registerer.
+func NewPusher(g prometheus.Gatherer, r prometheus.Registerer, sinks ...Sink) *Pusher {
+	failures := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "swift_health_exporter_push_failures_total",
+			Help: "Number of failed attempts to push the metric values to a sink.",
+		}, []string{"sink"},
+	)
+	r.MustRegister(failures)
+	for _, s := range sinks {
+		failures.WithLabelValues(s.Name()).Add(0)
+	}
+
+	return &Pusher{Gatherer: g, Sinks: sinks, failures: failures}
+}
+
+// Push pushes the current metric values to all Sinks, one after another, with
+// Timeout applied to each of them. Failures are logged and counted in the
+// failure metric.
+func (p *Pusher) Push(ctx context.Context) {
+	families, err := p.Gatherer.Gather()
+	if err != nil {
+		// Gather() returns as many metrics as possible even if there is an
+		// error, so we continue anyway.
+		logg.Error("could not gather metrics for pushing: %s", err.Error())
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultPushTimeout
+	}
+	for _, s := range p.Sinks {
+		pushCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := s.Push(pushCtx, families)
+		cancel()
+		if err != nil {
+			logg.Error("could not push metrics to %s: %s", s.Name(), err.Error())
+			p.failures.WithLabelValues(s.Name()).Inc()
+		}
+	}
+}
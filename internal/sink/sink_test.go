@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// testNow is the time at which the metric values in testFamilies() were
+// collected, unless they carry their own timestamp.
+var testNow = time.Unix(1700000000, 0).UTC()
+
+// testFamilies returns the metric families that the tests push to the sinks:
+// task metrics from recon (one of them restored from the state file, i.e.
+// with a timestamp), a counter and a summary as they would be reported by the
+// exporter itself and the Go runtime.
+func testFamilies() []*dto.MetricFamily {
+	return []*dto.MetricFamily{
+		{
+			Name: proto.String("swift_cluster_drives_unmounted"),
+			Help: proto.String("Unmounted drives reported by the swift-recon tool."),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				gaugeMetric(0, "storage_ip", "10.0.0.1"),
+				gaugeMetric(1, "storage_ip", "10.0.0.2"),
+			},
+		},
+		{
+			Name: proto.String("swift_cluster_md5_not_matched"),
+			Help: proto.String("Number of not matched md5sums."),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				withTimestamp(gaugeMetric(2, "kind", "ring", "storage_ip", "10.0.0.1"), testNow.Add(-time.Hour)),
+			},
+		},
+		{
+			Name: proto.String("swift_health_exporter_push_failures_total"),
+			Help: proto.String("Number of failed attempts to push the metric values to a sink."),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label:   labelPairs("sink", "statsd"),
+				Counter: &dto.Counter{Value: proto.Float64(3)},
+			}},
+		},
+		{
+			Name: proto.String("go_gc_duration_seconds"),
+			Help: proto.String("A summary of the wall-time pause duration in garbage collection cycles."),
+			Type: dto.MetricType_SUMMARY.Enum(),
+			Metric: []*dto.Metric{{
+				Summary: &dto.Summary{SampleCount: proto.Uint64(1), SampleSum: proto.Float64(0.001)},
+			}},
+		},
+	}
+}
+
+// gaugeMetric returns a gauge metric with the given value and labels, which
+// are given as alternating names and values.
+func gaugeMetric(value float64, labels ...string) *dto.Metric {
+	return &dto.Metric{
+		Label: labelPairs(labels...),
+		Gauge: &dto.Gauge{Value: proto.Float64(value)},
+	}
+}
+
+func labelPairs(labels ...string) []*dto.LabelPair {
+	result := make([]*dto.LabelPair, 0, len(labels)/2)
+	for idx := 0; idx+1 < len(labels); idx += 2 {
+		result = append(result, &dto.LabelPair{Name: proto.String(labels[idx]), Value: proto.String(labels[idx+1])})
+	}
+	return result
+}
+
+func withTimestamp(m *dto.Metric, t time.Time) *dto.Metric {
+	m.TimestampMs = proto.Int64(t.UnixMilli())
+	return m
+}
+
+// funcSink is a Sink that calls the given function on each push.
+type funcSink struct {
+	name string
+	push func(ctx context.Context, families []*dto.MetricFamily) error
+}
+
+func (s funcSink) Name() string {
+	return s.name
+}
+
+func (s funcSink) Push(ctx context.Context, families []*dto.MetricFamily) error {
+	return s.push(ctx, families)
+}
+
+func TestPusher(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return testFamilies(), nil
+	})
+
+	// A sink that does not respond is aborted after the timeout, and does not
+	// keep the pusher from pushing to the next sink.
+	var blockedErr error
+	var pushedFamilies int
+	pusher := NewPusher(gatherer, registry,
+		funcSink{"blocked", func(ctx context.Context, _ []*dto.MetricFamily) error {
+			<-ctx.Done()
+			blockedErr = ctx.Err()
+			return blockedErr
+		}},
+		funcSink{"working", func(_ context.Context, families []*dto.MetricFamily) error {
+			pushedFamilies = len(families)
+			return nil
+		}},
+	)
+	pusher.Timeout = 10 * time.Millisecond
+
+	startedAt := time.Now()
+	pusher.Push(t.Context())
+	if duration := time.Since(startedAt); duration > 5*time.Second {
+		t.Errorf("expected push to be aborted after the timeout, but it took %s", duration)
+	}
+	if !errors.Is(blockedErr, context.DeadlineExceeded) {
+		t.Errorf("expected the blocked sink to see the deadline, got %v", blockedErr)
+	}
+	if pushedFamilies != len(testFamilies()) {
+		t.Errorf("expected %d families to be pushed to the working sink, got %d", len(testFamilies()), pushedFamilies)
+	}
+
+	// Only the failed push is counted.
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			failures[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	if failures["blocked"] != 1 || failures["working"] != 0 {
+		t.Errorf("expected one failure for the blocked sink and none for the working sink, got %v", failures)
+	}
+}
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.yaml.in/yaml/v3"
+)
+
+// Max. size of a single UDP packet. This is the value that is recommended by
+// StatsD for Ethernet networks.
+const statsdMaxPacketSize = 1432
+
+// StatsD is a Sink that sends the metric values from the recon and dispersion
+// tasks to a StatsD server as gauges, e.g. for legacy Graphite dashboards.
+type StatsD struct {
+	Address  string // host:port of the StatsD server (UDP)
+	Prefix   string // optional, prepended to all names
+	Mappings []StatsDMapping
+}
+
+// StatsDMapping maps a metric to a dotted StatsD name. The template can refer
+// to label values with "{label}", e.g.
+//
+//	match: swift_cluster_md5_not_matched
+//	name: md5.{kind}.{storage_ip}.not_matched
+//
+// Metrics without a mapping are sent as "<metric>.<label values...>" without
+// the "swift_" prefix of the metric name and with the label values sorted by
+// label name.
+type StatsDMapping struct {
+	Match string `yaml:"match"`
+	Name  string `yaml:"name"`
+}
+
+// StatsDMappingFile is the content of the StatsD mapping file.
+type StatsDMappingFile struct {
+	Mappings []StatsDMapping `yaml:"mappings"`
+}
+
+// LoadStatsDMappings reads the StatsD mapping file at the given path.
+func LoadStatsDMappings(path string) ([]StatsDMapping, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f StatsDMappingFile
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	err = dec.Decode(&f)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	for idx, m := range f.Mappings {
+		if m.Match == "" || m.Name == "" {
+			return nil, fmt.Errorf("could not parse %s: mappings[%d] must have match and name", path, idx)
+		}
+	}
+
+	return f.Mappings, nil
+}
+
+// Name implements the Sink interface.
+func (s StatsD) Name() string {
+	return "statsd"
+}
+
+// Push implements the Sink interface.
+func (s StatsD) Push(ctx context.Context, families []*dto.MetricFamily) error {
+	lines, errs := s.lines(families)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", s.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var packet []byte
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > statsdMaxPacketSize {
+			_, err := conn.Write(packet)
+			if err != nil {
+				return err
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		_, err := conn.Write(packet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// lines returns the StatsD gauge lines for the given metric families.
+//
+// StatsD interprets a leading sign in a gauge value as a relative change, so
+// negative values are preceded by a reset to zero. Both lines are returned as
+// one entry, so that they are sent in the same packet and in this order.
+func (s StatsD) lines(families []*dto.MetricFamily) (lines []string, errs []error) {
+	mappings := make(map[string]string, len(s.Mappings))
+	for _, m := range s.Mappings {
+		mappings[m.Match] = m.Name
+	}
+
+	for _, mf := range families {
+		if !isTaskMetric(mf) {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+
+			var name string
+			if template, exists := mappings[mf.GetName()]; exists {
+				var err error
+				name, err = expandStatsDTemplate(template, labels)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("cannot map %s: %w", mf.GetName(), err))
+					continue
+				}
+			} else {
+				parts := []string{strings.TrimPrefix(mf.GetName(), "swift_")}
+				for _, label := range slices.Sorted(maps.Keys(labels)) {
+					parts = append(parts, sanitizeStatsDName(labels[label]))
+				}
+				name = strings.Join(parts, ".")
+			}
+			if s.Prefix != "" {
+				name = s.Prefix + "." + name
+			}
+
+			value := m.GetGauge().GetValue()
+			line := name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|g"
+			if value < 0 {
+				line = name + ":0|g\n" + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, errs
+}
+
+// isTaskMetric returns whether the metric family was reported by a recon or
+// dispersion task, as opposed to e.g. the Go runtime metrics.
+func isTaskMetric(mf *dto.MetricFamily) bool {
+	return mf.GetType() == dto.MetricType_GAUGE &&
+		strings.HasPrefix(mf.GetName(), "swift_") &&
+		!strings.HasPrefix(mf.GetName(), "swift_health_exporter_")
+}
+
+var statsdTemplateRx = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+func expandStatsDTemplate(template string, labels map[string]string) (string, error) {
+	var err error
+	result := statsdTemplateRx.ReplaceAllStringFunc(template, func(match string) string {
+		label := match[1 : len(match)-1]
+		value, exists := labels[label]
+		if !exists {
+			err = fmt.Errorf("unknown label %q in %q", label, template)
+		}
+		return sanitizeStatsDName(value)
+	})
+	return result, err
+}
+
+var statsdInvalidCharsRx = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// sanitizeStatsDName replaces all characters in a label value that have a
+// special meaning in StatsD names (most notably dots in IP addresses).
+func sanitizeStatsDName(value string) string {
+	return statsdInvalidCharsRx.ReplaceAllString(value, "_")
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package sink
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sapcc/go-bits/must"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStatsD(t *testing.T) {
+	conn := must.ReturnT(net.ListenPacket("udp", "127.0.0.1:0"))(t)
+	defer conn.Close()
+
+	err := StatsD{
+		Address: conn.LocalAddr().String(),
+		Prefix:  "swift",
+		Mappings: []StatsDMapping{{
+			Match: "swift_cluster_md5_not_matched",
+			Name:  "md5.{kind}.{storage_ip}.not_matched",
+		}},
+	}.Push(t.Context(), testFamilies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the task metrics are sent: The counter from the exporter itself
+	// and the Go runtime summary are skipped. Label values are sanitized.
+	received := receiveStatsDLines(t, conn)
+	expected := []string{
+		"swift.cluster_drives_unmounted.10_0_0_1:0|g",
+		"swift.cluster_drives_unmounted.10_0_0_2:1|g",
+		"swift.md5.ring.10_0_0_1.not_matched:2|g",
+	}
+	slices.Sort(received)
+	if !slices.Equal(received, expected) {
+		t.Errorf("expected StatsD lines %q, got %q", expected, received)
+	}
+}
+
+func TestStatsDNegativeValues(t *testing.T) {
+	conn := must.ReturnT(net.ListenPacket("udp", "127.0.0.1:0"))(t)
+	defer conn.Close()
+
+	families := []*dto.MetricFamily{{
+		Name: proto.String("swift_cluster_drives_unmounted"),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{
+			gaugeMetric(-1.5, "storage_ip", "10.0.0.1"),
+			gaugeMetric(2, "storage_ip", "10.0.0.2"),
+		},
+	}}
+	must.SucceedT(t, StatsD{Address: conn.LocalAddr().String()}.Push(t.Context(), families))
+
+	// A negative value would be taken as a decrement of the previous value, so
+	// the gauge is reset to zero right before.
+	received := receiveStatsDLines(t, conn)
+	expected := []string{
+		"cluster_drives_unmounted.10_0_0_1:0|g",
+		"cluster_drives_unmounted.10_0_0_1:-1.5|g",
+		"cluster_drives_unmounted.10_0_0_2:2|g",
+	}
+	if !slices.Equal(received, expected) {
+		t.Errorf("expected StatsD lines %q, got %q", expected, received)
+	}
+}
+
+func TestStatsDPacketSize(t *testing.T) {
+	conn := must.ReturnT(net.ListenPacket("udp", "127.0.0.1:0"))(t)
+	defer conn.Close()
+
+	// 200 lines of ~50 bytes each do not fit into a single packet.
+	mf := &dto.MetricFamily{
+		Name: proto.String("swift_cluster_drives_unmounted"),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	for idx := range 200 {
+		mf.Metric = append(mf.Metric, gaugeMetric(float64(idx), "storage_ip", fmt.Sprintf("10.0.%d.%d", idx/256, idx%256)))
+	}
+	err := StatsD{Address: conn.LocalAddr().String()}.Push(t.Context(), []*dto.MetricFamily{mf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lineCount int
+	buf := make([]byte, 65536)
+	for packetCount := 0; ; packetCount++ {
+		must.SucceedT(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if packetCount < 2 {
+				t.Errorf("expected the lines to be split into multiple packets, got %d", packetCount)
+			}
+			break
+		}
+		if n > statsdMaxPacketSize {
+			t.Errorf("expected packets of at most %d bytes, got %d", statsdMaxPacketSize, n)
+		}
+		lineCount += len(strings.Split(string(buf[:n]), "\n"))
+	}
+	if lineCount != 200 {
+		t.Errorf("expected 200 lines, got %d", lineCount)
+	}
+}
+
+func TestStatsDMappingErrors(t *testing.T) {
+	_, errs := StatsD{Mappings: []StatsDMapping{{
+		Match: "swift_cluster_drives_unmounted",
+		Name:  "drives.{host}.unmounted",
+	}}}.lines(testFamilies())
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), `unknown label "host" in "drives.{host}.unmounted"`) {
+		t.Errorf("expected an error for each metric with an unknown label, got %v", errs)
+	}
+
+	dir := t.TempDir()
+	for content, expectedError := range map[string]string{
+		"mappings:\n- match: swift_cluster_drives_unmounted\n":                 "mappings[0] must have match and name",
+		"mappings:\n- match: swift_cluster_drives_unmounted\n  nmae: drives\n": "field nmae not found",
+	} {
+		path := filepath.Join(dir, "mappings.yaml")
+		must.SucceedT(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := LoadStatsDMappings(path)
+		if err == nil || !strings.Contains(err.Error(), expectedError) {
+			t.Errorf("expected error containing %q for %q, got %v", expectedError, content, err)
+		}
+	}
+}
+
+func receiveStatsDLines(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+	var result []string
+	buf := make([]byte, 2048)
+	for {
+		must.SucceedT(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return result
+		}
+		result = append(result, strings.Split(string(buf[:n]), "\n")...)
+	}
+}
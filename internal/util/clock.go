@@ -0,0 +1,20 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import "time"
+
+// Clock provides the current time. It is used by everything that depends on
+// the current time, so that tests can control it.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock that returns the actual current time.
+type SystemClock struct{}
+
+// Now implements the Clock interface.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Executor decides where the commands of RunCommandWithTimeout are run. It
+// wraps the command line into a local command line (e.g. of ssh or kubectl)
+// that runs the actual command somewhere else.
+type Executor interface {
+	// Wrap returns the local command line for the given command line.
+	// Timeout is the timeout of the command, for executors that need to
+	// enforce it on the remote side.
+	Wrap(name string, args []string, timeout time.Duration) (string, []string)
+	// IsLocal returns whether the command runs on the local host, in which
+	// case its executable is looked up locally.
+	IsLocal() bool
+}
+
+// LocalExecutor runs commands on the local host.
+type LocalExecutor struct{}
+
+// Wrap implements the Executor interface.
+func (LocalExecutor) Wrap(name string, args []string, _ time.Duration) (string, []string) {
+	return name, args
+}
+
+// IsLocal implements the Executor interface.
+func (LocalExecutor) IsLocal() bool {
+	return true
+}
+
+// SSHExecutor runs commands on a remote host (usually a jump host that has
+// access to the Swift cluster) through ssh with public key authentication.
+type SSHExecutor struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"` // optional
+	User string `yaml:"user"` // optional
+	// IdentityFile is the private key that is used for authentication.
+	IdentityFile string `yaml:"identity_file"`
+	// KnownHostsFile enables strict host key checking against the given
+	// file (optional).
+	KnownHostsFile string `yaml:"known_hosts_file"`
+	// ProxyJump is passed to ssh as -J to reach Host through a bastion host
+	// (optional).
+	ProxyJump string `yaml:"proxy_jump"`
+}
+
+// Wrap implements the Executor interface.
+func (e SSHExecutor) Wrap(name string, args []string, timeout time.Duration) (string, []string) {
+	sshArgs := []string{"-o", "BatchMode=yes", "-o", "IdentitiesOnly=yes", "-i", e.IdentityFile}
+	if e.KnownHostsFile != "" {
+		sshArgs = append(sshArgs, "-o", "StrictHostKeyChecking=yes", "-o", "UserKnownHostsFile="+e.KnownHostsFile)
+	}
+	if e.Port != 0 {
+		sshArgs = append(sshArgs, "-p", strconv.Itoa(e.Port))
+	}
+	if e.User != "" {
+		sshArgs = append(sshArgs, "-l", e.User)
+	}
+	if e.ProxyJump != "" {
+		sshArgs = append(sshArgs, "-J", e.ProxyJump)
+	}
+
+	// The remote command line is interpreted by the login shell.
+	remoteCmd := remoteCommand(name, args, timeout)
+	for idx, arg := range remoteCmd {
+		remoteCmd[idx] = shellQuote(arg)
+	}
+	sshArgs = append(sshArgs, e.Host, "--", strings.Join(remoteCmd, " "))
+	return "ssh", sshArgs
+}
+
+// IsLocal implements the Executor interface.
+func (SSHExecutor) IsLocal() bool {
+	return false
+}
+
+// KubectlExecutor runs commands in an existing pod through kubectl exec.
+type KubectlExecutor struct {
+	Kubeconfig string `yaml:"kubeconfig"` // optional
+	Context    string `yaml:"context"`    // optional
+	Namespace  string `yaml:"namespace"`  // optional
+	// Pod is the name of the pod, or any other resource that kubectl exec
+	// accepts (e.g. "deployment/swift-proxy").
+	Pod       string `yaml:"pod"`
+	Container string `yaml:"container"` // optional
+}
+
+// Wrap implements the Executor interface.
+func (e KubectlExecutor) Wrap(name string, args []string, timeout time.Duration) (string, []string) {
+	var kubectlArgs []string
+	if e.Kubeconfig != "" {
+		kubectlArgs = append(kubectlArgs, "--kubeconfig="+e.Kubeconfig)
+	}
+	if e.Context != "" {
+		kubectlArgs = append(kubectlArgs, "--context="+e.Context)
+	}
+	kubectlArgs = append(kubectlArgs, "exec")
+	if e.Namespace != "" {
+		kubectlArgs = append(kubectlArgs, "--namespace="+e.Namespace)
+	}
+	if e.Container != "" {
+		kubectlArgs = append(kubectlArgs, "--container="+e.Container)
+	}
+	kubectlArgs = append(kubectlArgs, e.Pod, "--")
+	return "kubectl", append(kubectlArgs, remoteCommand(name, args, timeout)...)
+}
+
+// IsLocal implements the Executor interface.
+func (KubectlExecutor) IsLocal() bool {
+	return false
+}
+
+// NsenterExecutor runs commands within the namespaces of an existing process,
+// usually the main process of a Swift pod on the same Kubernetes node. This
+// requires the exporter to run privileged and in the host PID namespace.
+type NsenterExecutor struct {
+	TargetPID int `yaml:"target_pid"`
+}
+
+// Wrap implements the Executor interface.
+func (e NsenterExecutor) Wrap(name string, args []string, _ time.Duration) (string, []string) {
+	// The command remains a child process of nsenter, so it is still covered
+	// by the process group handling in RunCommandWithTimeout.
+	nsenterArgs := []string{
+		"--target=" + strconv.Itoa(e.TargetPID),
+		"--mount", "--uts", "--ipc", "--net", "--pid",
+		"--", name,
+	}
+	return "nsenter", append(nsenterArgs, args...)
+}
+
+// IsLocal implements the Executor interface.
+func (NsenterExecutor) IsLocal() bool {
+	return false
+}
+
+// remoteCommand wraps the command line into the timeout command, so that the
+// command is also terminated on the remote side when the local client is
+// killed because of the timeout.
+func remoteCommand(name string, args []string, timeout time.Duration) []string {
+	result := []string{
+		"timeout",
+		"-k", strconv.FormatFloat(killGracePeriod.Seconds(), 'f', -1, 64),
+		strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64),
+		name,
+	}
+	return append(result, args...)
+}
+
+// shellQuote quotes the argument for a POSIX shell.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=./:,@") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ExecutorConfig selects and configures an Executor.
+//
+// Example:
+//
+//	type: ssh
+//	ssh:
+//	  host: swift-jump.example.com
+//	  user: swift-exporter
+//	  identity_file: /etc/swift-health-exporter/id_ed25519
+type ExecutorConfig struct {
+	// Type is one of "local" (the default), "ssh", "kubectl" or "nsenter".
+	// Only the section for the selected type is used.
+	Type    string          `yaml:"type"`
+	SSH     SSHExecutor     `yaml:"ssh"`
+	Kubectl KubectlExecutor `yaml:"kubectl"`
+	Nsenter NsenterExecutor `yaml:"nsenter"`
+}
+
+// Validate checks the configuration for errors.
+func (c ExecutorConfig) Validate() error {
+	var errs []error
+	switch c.Type {
+	case "", "local":
+	case "ssh":
+		if c.SSH.Host == "" {
+			errs = append(errs, errors.New("ssh.host is missing"))
+		}
+		if c.SSH.IdentityFile == "" {
+			errs = append(errs, errors.New("ssh.identity_file is missing"))
+		}
+	case "kubectl":
+		if c.Kubectl.Pod == "" {
+			errs = append(errs, errors.New("kubectl.pod is missing"))
+		}
+	case "nsenter":
+		if c.Nsenter.TargetPID <= 0 {
+			errs = append(errs, errors.New("nsenter.target_pid must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown type: %q", c.Type))
+	}
+	return errors.Join(errs...)
+}
+
+// Executor returns the configured Executor. The configuration must have been
+// validated with Validate() before.
+func (c ExecutorConfig) Executor() Executor {
+	switch c.Type {
+	case "ssh":
+		return c.SSH
+	case "kubectl":
+		return c.Kubectl
+	case "nsenter":
+		return c.Nsenter
+	default:
+		return LocalExecutor{}
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sapcc/go-bits/must"
+)
+
+func TestExecutorWrap(t *testing.T) {
+	testCases := []struct {
+		Config       ExecutorConfig
+		ExpectedArgs []string
+	}{
+		{
+			Config:       ExecutorConfig{},
+			ExpectedArgs: []string{"swift-recon", "--md5", "--timeout=1"},
+		},
+		{
+			Config: ExecutorConfig{Type: "ssh", SSH: SSHExecutor{
+				Host:           "swift-jump.example.com",
+				Port:           2222,
+				User:           "swift-exporter",
+				IdentityFile:   "/etc/id_ed25519",
+				KnownHostsFile: "/etc/known_hosts",
+				ProxyJump:      "bastion.example.com",
+			}},
+			ExpectedArgs: []string{
+				"ssh", "-o", "BatchMode=yes", "-o", "IdentitiesOnly=yes", "-i", "/etc/id_ed25519",
+				"-o", "StrictHostKeyChecking=yes", "-o", "UserKnownHostsFile=/etc/known_hosts",
+				"-p", "2222", "-l", "swift-exporter", "-J", "bastion.example.com",
+				"swift-jump.example.com", "--", "timeout -k 5 20 swift-recon --md5 --timeout=1",
+			},
+		},
+		{
+			Config: ExecutorConfig{Type: "kubectl", Kubectl: KubectlExecutor{
+				Kubeconfig: "/etc/kubeconfig",
+				Context:    "eu-de-1",
+				Namespace:  "swift",
+				Pod:        "swift-proxy-0",
+				Container:  "proxy",
+			}},
+			ExpectedArgs: []string{
+				"kubectl", "--kubeconfig=/etc/kubeconfig", "--context=eu-de-1", "exec", "--namespace=swift", "--container=proxy", "swift-proxy-0",
+				"--", "timeout", "-k", "5", "20", "swift-recon", "--md5", "--timeout=1",
+			},
+		},
+		{
+			Config: ExecutorConfig{Type: "nsenter", Nsenter: NsenterExecutor{TargetPID: 42}},
+			ExpectedArgs: []string{
+				"nsenter", "--target=42", "--mount", "--uts", "--ipc", "--net", "--pid", "--", "swift-recon", "--md5", "--timeout=1",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		must.SucceedT(t, tc.Config.Validate())
+		name, args := tc.Config.Executor().Wrap("swift-recon", []string{"--md5", "--timeout=1"}, 20*time.Second)
+		if actual := append([]string{name}, args...); !slices.Equal(actual, tc.ExpectedArgs) {
+			t.Errorf("executor %q: expected command line %q, got %q", tc.Config.Type, tc.ExpectedArgs, actual)
+		}
+	}
+
+	// Arguments for the remote login shell are quoted.
+	_, args := SSHExecutor{Host: "swift-jump"}.Wrap("sh", []string{"-c", "echo 'it works'"}, time.Second)
+	if expected := `timeout -k 5 1 sh -c 'echo '\''it works'\'''`; args[len(args)-1] != expected {
+		t.Errorf("expected remote command line %q, got %q", expected, args[len(args)-1])
+	}
+}
+
+func TestExecutorValidate(t *testing.T) {
+	for cfg, expectedError := range map[ExecutorConfig]string{
+		{Type: "ssh"}:     "ssh.host is missing\nssh.identity_file is missing",
+		{Type: "kubectl"}: "kubectl.pod is missing",
+		{Type: "nsenter"}: "nsenter.target_pid must be positive",
+		{Type: "docker"}:  `unknown type: "docker"`,
+		{Type: "ssh", SSH: SSHExecutor{Host: "a"}}: "ssh.identity_file is missing",
+	} {
+		err := cfg.Validate()
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("expected error %q for %+v, got %v", expectedError, cfg, err)
+		}
+	}
+}
+
+func TestExecutors(t *testing.T) {
+	// The fake ssh and kubectl run the remote command line locally, and leave
+	// a marker file to show that they were used.
+	binDir := t.TempDir()
+	must.SucceedT(t, os.WriteFile(filepath.Join(binDir, "ssh"),
+		[]byte("#!/bin/sh\ntouch \"$0.used\"\nwhile [ \"$1\" != -- ]; do shift; done\nexec sh -c \"$2\"\n"), 0o755))
+	must.SucceedT(t, os.WriteFile(filepath.Join(binDir, "kubectl"),
+		[]byte("#!/bin/sh\ntouch \"$0.used\"\nwhile [ \"$1\" != -- ]; do shift; done\nshift\nexec \"$@\"\n"), 0o755))
+	t.Setenv("PATH", binDir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+
+	for _, executor := range []ExecutorConfig{
+		{Type: "ssh", SSH: SSHExecutor{Host: "swift-jump.example.com", IdentityFile: "/etc/id_ed25519"}},
+		{Type: "kubectl", Kubectl: KubectlExecutor{Namespace: "swift", Pod: "swift-proxy-0"}},
+	} {
+		t.Run(executor.Type, func(t *testing.T) {
+			out, err := RunCommandWithTimeout(t.Context(), executor.Executor(), 10*time.Second, "sh", "-c", "echo 'it works'; echo \"$0\"", "remote")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); !slices.Equal(lines, []string{"it works", "remote"}) {
+				t.Errorf("expected the arguments to be passed unchanged, got %q", lines)
+			}
+			if _, err := os.Stat(filepath.Join(binDir, executor.Type+".used")); err != nil {
+				t.Errorf("expected the command to be run through %s, but it was not", executor.Type)
+			}
+		})
+	}
+}
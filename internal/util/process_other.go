@@ -0,0 +1,22 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !unix
+
+package util
+
+import (
+	"os/exec"
+)
+
+// setupProcessGroup is a reduced version of the Unix implementation: Process
+// groups are not available, so only the command itself is killed when its
+// context is cancelled.
+func setupProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = killGracePeriod
+}
+
+// killProcessGroup kills the command itself, see setupProcessGroup.
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package util
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setupProcessGroup makes the command run in its own process group, and makes
+// the cancellation of its context send SIGTERM to the whole process group.
+// If the command is still running after killGracePeriod, exec.Cmd kills it.
+func setupProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative PID addresses the process group.
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killGracePeriod
+}
+
+// killProcessGroup sends SIGKILL to the process group of a command that was
+// set up with setupProcessGroup.
+func killProcessGroup(cmd *exec.Cmd) {
+	// ESRCH (no such process) is expected if all processes have exited.
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
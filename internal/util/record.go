@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sapcc/go-bits/logg"
+)
+
+// Recording is the record of a single command invocation by
+// RunCommandWithTimeout. Recordings are written to the directory given to
+// StartRecording, one JSON file per invocation.
+type Recording struct {
+	Command   string    `json:"command"`
+	Args      []string  `json:"args"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+	Stdout    string    `json:"stdout"`
+	Stderr    string    `json:"stderr"`
+	ExitCode  int       `json:"exit_code"`
+	// Error is the error that was returned for the invocation, e.g. "exit
+	// status 1" or "signal: killed" (empty on success).
+	Error string `json:"error,omitempty"`
+	// TimedOut and Timeout are set if the command was killed because of its
+	// timeout.
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+var recordState struct {
+	mu        sync.Mutex
+	recordDir string
+	replay    map[string]*replaySeries // key = replayKey(); nil if not replaying
+}
+
+// replaySeries holds all recordings for the same command and arguments. They
+// are served in the order in which they were recorded; after the last one, the
+// series starts over.
+type replaySeries struct {
+	recordings []Recording
+	next       int
+}
+
+// StartRecording enables the recording of all subsequent command invocations
+// by RunCommandWithTimeout into the given directory. The directory is created
+// if necessary. An empty dir disables recording.
+func StartRecording(dir string) error {
+	if dir != "" {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+	recordState.mu.Lock()
+	defer recordState.mu.Unlock()
+	recordState.recordDir = dir
+	return nil
+}
+
+// StartReplay loads the recordings from the given directory (as written by
+// StartRecording). All subsequent invocations of RunCommandWithTimeout are
+// served from these recordings instead of executing the command. Commands are
+// matched by the base name of the executable and the exact arguments. An empty
+// dir disables replay.
+func StartReplay(dir string) error {
+	if dir == "" {
+		recordState.mu.Lock()
+		defer recordState.mu.Unlock()
+		recordState.replay = nil
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no recordings found in %s", dir)
+	}
+
+	replay := make(map[string]*replaySeries)
+	for _, path := range paths {
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var r Recording
+		err = json.Unmarshal(buf, &r)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %w", path, err)
+		}
+		key := replayKey(r.Command, r.Args)
+		if replay[key] == nil {
+			replay[key] = &replaySeries{}
+		}
+		replay[key].recordings = append(replay[key].recordings, r)
+	}
+	for _, series := range replay {
+		slices.SortStableFunc(series.recordings, func(a, b Recording) int {
+			return a.StartedAt.Compare(b.StartedAt)
+		})
+	}
+
+	recordState.mu.Lock()
+	defer recordState.mu.Unlock()
+	recordState.replay = replay
+	return nil
+}
+
+// IsReplaying returns whether StartReplay has been called.
+func IsReplaying() bool {
+	recordState.mu.Lock()
+	defer recordState.mu.Unlock()
+	return recordState.replay != nil
+}
+
+func replayKey(name string, args []string) string {
+	return strings.Join(append([]string{filepath.Base(name)}, args...), "\x00")
+}
+
+// replayCommand returns the stdout and error of the next recording for the
+// given command. The second return value is false if replay mode is not
+// enabled.
+func replayCommand(name string, args []string) ([]byte, bool, error) {
+	recordState.mu.Lock()
+	defer recordState.mu.Unlock()
+	if recordState.replay == nil {
+		return nil, false, nil
+	}
+
+	series := recordState.replay[replayKey(name, args)]
+	if series == nil {
+		return nil, true, fmt.Errorf("no recording found for %q", strings.Join(append([]string{filepath.Base(name)}, args...), " "))
+	}
+	r := series.recordings[series.next]
+	series.next = (series.next + 1) % len(series.recordings)
+
+	if r.Error != "" {
+		timeout, _ := time.ParseDuration(r.Timeout)
+		return []byte(r.Stdout), true, &CommandError{
+			Inner:    errors.New(r.Error),
+			ExitCode: r.ExitCode,
+			TimedOut: r.TimedOut,
+			Timeout:  timeout,
+			Stderr:   r.Stderr,
+		}
+	}
+	return []byte(r.Stdout), true, nil
+}
+
+// recordCommand writes a Recording of a finished command into the record
+// directory (if enabled). The command line is recorded as given to
+// RunCommandWithTimeout, i.e. without the wrapping by the Executor.
+func recordCommand(cmd *exec.Cmd, name string, args []string, startedAt time.Time, duration time.Duration, stdout, stderr []byte, cmdErr *CommandError) {
+	recordState.mu.Lock()
+	dir := recordState.recordDir
+	recordState.mu.Unlock()
+	if dir == "" {
+		return
+	}
+
+	r := Recording{
+		Command:   name,
+		Args:      args,
+		StartedAt: startedAt.UTC(),
+		Duration:  duration.String(),
+		Stdout:    string(stdout),
+		Stderr:    string(stderr),
+	}
+	if cmd.ProcessState != nil {
+		r.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if cmdErr != nil {
+		r.Error = cmdErr.Inner.Error()
+		r.TimedOut = cmdErr.TimedOut
+		if cmdErr.TimedOut {
+			r.Timeout = cmdErr.Timeout.String()
+		}
+	}
+
+	// Failing to record must not fail the command itself.
+	err := writeRecording(dir, r)
+	if err != nil {
+		logg.Error("could not record invocation of %s: %s", name, err.Error())
+	}
+}
+
+func writeRecording(dir string, r Recording) error {
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	prefix := fmt.Sprintf("%s-%s-", r.StartedAt.Format("20060102T150405.000000000Z"), filepath.Base(r.Command))
+	file, err := os.CreateTemp(dir, prefix+"*.json")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(buf, '\n'))
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -5,17 +5,148 @@ package util
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os/exec"
 	"strings"
 	"time"
 )
 
-// RunCommandWithTimeout runs a command with the provided timeout duration and returns its
-// combined output.
-func RunCommandWithTimeout(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+// Limits for the output of commands that are run by RunCommandWithTimeout.
+// swift-recon output is usually a few KiB per host, so these limits are only
+// reached by commands that misbehave.
+const (
+	maxStdoutSize = 16 << 20 // 16 MiB
+	maxStderrSize = 64 << 10 // 64 KiB
+)
+
+// How long a command is given to exit after its process group has been sent
+// SIGTERM because of a timeout. After that, the process group is sent SIGKILL.
+const killGracePeriod = 5 * time.Second
+
+// CommandError is the error type that RunCommandWithTimeout returns when a
+// command fails.
+type CommandError struct {
+	// Inner is the underlying error, e.g. an *exec.ExitError.
+	Inner error
+	// ExitCode is the exit code of the command, or -1 if the command did not
+	// exit by itself (e.g. because it was killed).
+	ExitCode int
+	// TimedOut is true if the command was killed because it did not finish
+	// within its timeout.
+	TimedOut bool
+	Timeout  time.Duration
+	// Stderr is the standard error of the command. It is truncated to a few
+	// KiB.
+	Stderr string
+}
+
+func (e *CommandError) Error() string {
+	if e.TimedOut {
+		return "timed out after " + e.Timeout.String()
+	}
+	return e.Inner.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Inner
+}
+
+// RunCommandWithTimeout runs a command through the given executor (nil for
+// LocalExecutor) with the provided timeout duration and returns its standard
+// output. If the command fails, the error is a *CommandError, which also
+// contains the standard error.
+//
+// The command is started in its own process group. When the timeout is
+// reached, the whole process group is sent SIGTERM, and SIGKILL if the command
+// does not exit within a grace period, so that worker processes spawned by
+// the command do not linger.
+//
+// The invocation is recorded if StartRecording has been called. If StartReplay
+// has been called, the command is not executed and the recorded output is
+// returned instead.
+func RunCommandWithTimeout(ctx context.Context, executor Executor, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if out, isReplaying, err := replayCommand(name, args); isReplaying {
+		return out, err
+	}
+	if executor == nil {
+		executor = LocalExecutor{}
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+	cmdName, cmdArgs := executor.Wrap(name, args, timeout)
+	cmd := exec.CommandContext(ctx, cmdName, cmdArgs...)
+	stdout := cappedBuffer{limit: maxStdoutSize}
+	stderr := cappedBuffer{limit: maxStderrSize}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	setupProcessGroup(cmd)
+
+	startedAt := time.Now()
+	err := cmd.Run()
+	duration := time.Since(startedAt)
+	if cmd.Process != nil && ctx.Err() != nil {
+		// The process group was only sent SIGTERM so far. This kills the
+		// processes that ignored it or that outlived the command itself.
+		killProcessGroup(cmd)
+	}
+
+	var cmdErr *CommandError
+	switch {
+	case err != nil:
+		cmdErr = &CommandError{
+			Inner:    err,
+			ExitCode: -1,
+			TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+			Timeout:  timeout,
+			Stderr:   stderr.String(),
+		}
+		if cmd.ProcessState != nil {
+			cmdErr.ExitCode = cmd.ProcessState.ExitCode()
+		}
+	case stdout.truncated:
+		cmdErr = &CommandError{
+			Inner:  fmt.Errorf("output exceeds the limit of %d bytes", stdout.limit),
+			Stderr: stderr.String(),
+		}
+	}
+
+	recordCommand(cmd, name, args, startedAt, duration, stdout.Bytes(), stderr.Bytes(), cmdErr)
+	if cmdErr != nil {
+		return stdout.Bytes(), cmdErr
+	}
+	return stdout.Bytes(), nil
+}
+
+// cappedBuffer is a bytes buffer that discards everything that is written to
+// it beyond its limit.
+type cappedBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := min(len(p), b.limit-len(b.buf))
+	if n < len(p) {
+		b.truncated = true
+	}
+	b.buf = append(b.buf, p[:n]...)
+	// Report everything as written, so that the command does not fail
+	// because of a broken pipe.
+	return len(p), nil
+}
+
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return string(b.buf) + "\n[truncated]"
+	}
+	return string(b.buf)
 }
 
 // CmdArgsToStr returns a space separated string for cmdArgs.
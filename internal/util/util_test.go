@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommand(t *testing.T) {
+	// A failing command reports its exit code and stderr separately from stdout.
+	out, err := RunCommandWithTimeout(t.Context(), nil, 10*time.Second, "sh", "-c", "echo out; echo err >&2; exit 3")
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expected a CommandError, got %v", err)
+	}
+	if string(out) != "out\n" || cmdErr.Stderr != "err\n" || cmdErr.ExitCode != 3 || cmdErr.TimedOut {
+		t.Errorf("unexpected result for failing command: stdout = %q, error = %#v", string(out), cmdErr)
+	}
+
+	// On timeout, the whole process group is killed, including workers that
+	// ignore SIGTERM (the ignored signal is inherited from the shell).
+	out, err = RunCommandWithTimeout(t.Context(), nil, 100*time.Millisecond, "sh", "-c", `trap "" TERM; sleep 60 & echo $!; wait`)
+	if !errors.As(err, &cmdErr) || !cmdErr.TimedOut || cmdErr.Error() != "timed out after 100ms" {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	workerIsRunning := func() bool {
+		buf, err := os.ReadFile("/proc/" + strings.TrimSpace(string(out)) + "/stat")
+		return err == nil && !strings.Contains(string(buf), ") Z ") // zombies are dead already
+	}
+	for range 50 {
+		if !workerIsRunning() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("expected worker process to be killed, but it is still running")
+}
+
+func TestRunCommandOutputLimit(t *testing.T) {
+	out, err := RunCommandWithTimeout(t.Context(), nil, 10*time.Second, "head", "-c", "16777217", "/dev/zero")
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Error() != "output exceeds the limit of 16777216 bytes" {
+		t.Errorf("expected output limit error, got %v", err)
+	}
+	if len(out) != maxStdoutSize {
+		t.Errorf("expected output to be truncated to %d bytes, got %d", maxStdoutSize, len(out))
+	}
+}
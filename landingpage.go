@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sapcc/go-bits/httpapi"
+	"github.com/sapcc/go-bits/logg"
+
+	"github.com/sapcc/swift-health-exporter/internal/collector"
+)
+
+// landingPageAPI serves the status page of the local target at "/".
+type landingPageAPI struct {
+	Target *target
+	// Replication ages above this threshold are highlighted.
+	ReplicationAgeThreshold time.Duration
+}
+
+func (a landingPageAPI) AddTo(r *mux.Router) {
+	r.Methods("GET", "HEAD").Path("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.IdentifyEndpoint(r, "/")
+
+		families, err := a.Target.collector.Gather()
+		if err != nil {
+			// Gather() returns as many metrics as possible even if there is
+			// an error, so we continue anyway.
+			logg.Error("could not gather metrics for the status page: %s", err.Error())
+		}
+		data := struct {
+			Tasks []collector.TaskStatus
+			Hosts []hostHealth
+		}{
+			Tasks: a.Target.scraper.Status(),
+			Hosts: buildHostHealth(families, a.ReplicationAgeThreshold),
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = landingPageTemplate.Execute(w, data)
+		if err != nil {
+			logg.Error(err.Error())
+		}
+	})
+}
+
+// hostHealth is a row in the per-host health matrix on the status page.
+type hostHealth struct {
+	Hostname       string
+	MD5            healthCell
+	Unmounted      healthCell
+	ReplicationAge healthCell
+}
+
+// healthCell is a cell in the per-host health matrix. The zero value is
+// rendered as a cell without data.
+type healthCell struct {
+	Text  string
+	Class string // "ok", "warn" or "bad"
+}
+
+// buildHostHealth computes the per-host health matrix from the metrics of the
+// recon.md5, recon.unmounted and recon.replication collectors.
+func buildHostHealth(families []*dto.MetricFamily, replicationAgeThreshold time.Duration) []hostHealth {
+	hosts := make(map[string]*hostHealth)
+	forEachHost := func(mf *dto.MetricFamily, action func(h *hostHealth, value float64)) {
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() != "storage_ip" {
+					continue
+				}
+				h := hosts[lp.GetValue()]
+				if h == nil {
+					h = &hostHealth{Hostname: lp.GetValue()}
+					hosts[lp.GetValue()] = h
+				}
+				action(h, m.GetGauge().GetValue())
+			}
+		}
+	}
+
+	// The md5 state of a host is the worst state of all kinds of md5sums, so
+	// the metrics are processed from the best to the worst state.
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, mf := range families {
+		byName[mf.GetName()] = mf
+	}
+	md5States := []struct {
+		MetricName string
+		Cell       healthCell
+	}{
+		{"swift_cluster_md5_matched", healthCell{"ok", "ok"}},
+		{"swift_cluster_md5_errors", healthCell{"error", "warn"}},
+		{"swift_cluster_md5_not_matched", healthCell{"mismatch", "bad"}},
+	}
+	for _, state := range md5States {
+		if mf := byName[state.MetricName]; mf != nil {
+			forEachHost(mf, func(h *hostHealth, value float64) {
+				if value > 0 {
+					h.MD5 = state.Cell
+				}
+			})
+		}
+	}
+
+	if mf := byName["swift_cluster_drives_unmounted"]; mf != nil {
+		forEachHost(mf, func(h *hostHealth, value float64) {
+			h.Unmounted = healthCell{strconv.FormatFloat(value, 'f', -1, 64), "ok"}
+			if value > 0 {
+				h.Unmounted.Class = "bad"
+			}
+		})
+	}
+
+	// The replication age of a host is the maximum of all server types.
+	maxAge := make(map[string]float64)
+	for _, name := range []string{"swift_cluster_accounts_replication_age", "swift_cluster_containers_replication_age", "swift_cluster_objects_replication_age"} {
+		if mf := byName[name]; mf != nil {
+			forEachHost(mf, func(h *hostHealth, value float64) {
+				// Negative values indicate that the host did not report a
+				// replication time.
+				if value >= 0 {
+					maxAge[h.Hostname] = max(maxAge[h.Hostname], value)
+				}
+			})
+		}
+	}
+	for hostname, age := range maxAge {
+		d := (time.Duration(age) * time.Second).Round(time.Second)
+		cell := healthCell{d.String(), "ok"}
+		if d > replicationAgeThreshold {
+			cell.Class = "bad"
+		}
+		hosts[hostname].ReplicationAge = cell
+	}
+
+	result := make([]hostHealth, 0, len(hosts))
+	for _, hostname := range slices.Sorted(maps.Keys(hosts)) {
+		result = append(result, *hosts[hostname])
+	}
+	return result
+}
+
+var landingPageTemplate = template.Must(template.New("landing").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<html>
+<head>
+<title>Swift Health Exporter</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 2px 6px; text-align: left; }
+.ok { background-color: #cfc; }
+.warn { background-color: #ffc; }
+.bad { background-color: #fcc; }
+</style>
+</head>
+<body>
+<h1>Swift Health Exporter</h1>
+<p><a href="/metrics">Metrics</a> | <a href="/errors">Recent Errors</a> | <a href="https://github.com/sapcc/swift-health-exporter">Source Code</a></p>
+
+<h2>Tasks</h2>
+<table>
+<tr><th>Task</th><th>Interval</th><th>Last run</th><th>Duration</th><th>Failures</th><th>Last error</th></tr>
+{{- range .Tasks }}
+<tr>
+<td>{{ .Name }}</td>
+<td>{{ .Interval }}</td>
+<td>{{ if .LastRunAt.IsZero }}never{{ else }}{{ formatTime .LastRunAt }}{{ end }}</td>
+<td>{{ if not .LastRunAt.IsZero }}{{ .LastDuration }}{{ end }}</td>
+<td class="{{ if .Failed }}bad{{ else if .FailureCount }}warn{{ else }}ok{{ end }}">{{ .FailureCount }}</td>
+<td>{{ with .LastError }}{{ formatTime .Time }}{{ with .Hostname }} ({{ . }}){{ end }}: {{ .Message }}{{ end }}</td>
+</tr>
+{{- end }}
+</table>
+{{- if .Hosts }}
+
+<h2>Storage nodes</h2>
+<table>
+<tr><th>Host</th><th>md5sums</th><th>Unmounted drives</th><th>Replication age</th></tr>
+{{- range .Hosts }}
+<tr>
+<td>{{ .Hostname }}</td>
+<td{{ with .MD5.Class }} class="{{ . }}"{{ end }}>{{ .MD5.Text }}</td>
+<td{{ with .Unmounted.Class }} class="{{ . }}"{{ end }}>{{ .Unmounted.Text }}</td>
+<td{{ with .ReplicationAge.Class }} class="{{ . }}"{{ end }}>{{ .ReplicationAge.Text }}</td>
+</tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
@@ -5,15 +5,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 	"github.com/sapcc/go-api-declarations/bininfo"
 	"github.com/sapcc/go-bits/httpapi"
 	"github.com/sapcc/go-bits/httpapi/pprofapi"
@@ -24,19 +32,64 @@ import (
 	flag "github.com/spf13/pflag"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
-	"github.com/sapcc/swift-health-exporter/internal/collector/dispersion"
-	"github.com/sapcc/swift-health-exporter/internal/collector/recon"
+	"github.com/sapcc/swift-health-exporter/internal/config"
+	"github.com/sapcc/swift-health-exporter/internal/probe"
+	"github.com/sapcc/swift-health-exporter/internal/sink"
+	"github.com/sapcc/swift-health-exporter/internal/util"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			ctx := httpext.ContextWithSIGINT(context.Background(), 1*time.Second)
+			os.Exit(runCheck(ctx, os.Args[2:], os.Stdout))
+		case "generate":
+			os.Exit(runGenerate(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	var (
 		debug            bool
 		showVersion      bool
 		webListenAddress string
+		webConfigFile    string
+		probeConfigFile  string
+		configFile       string
+		stateFile        string
+		once             bool
+		outputTextfile   string
+		enableLifecycle  bool
+		recordDir        string
+		replayDir        string
+
+		pushGatewayURL string
+		pushJob        string
+		pushGrouping   map[string]string
+
+		statsdAddress     string
+		statsdPrefix      string
+		statsdMappingFile string
+
+		otlpEndpoint           string
+		otlpResourceAttributes map[string]string
+
+		remoteWriteURL            string
+		remoteWriteExternalLabels map[string]string
+		remoteWriteMaxBufferAge   time.Duration
+
+		alertingWebhookURL              string
+		alertingLabels                  map[string]string
+		alertingRepeatInterval          time.Duration
+		alertingReplicationAgeThreshold time.Duration
 
 		maxFailures int
 
-		// In large Swift clusters the dispersion-report tool takes time, therefore we have a higher default timeout value.
+		scrapeOnDemand      bool
+		scrapeMinAge        int64
+		scrapeTimeout       int64
+		scrapeTimeoutOffset float64
+
 		dispersionTimeout   int64
 		dispersionCollector bool
 
@@ -55,9 +108,38 @@ func main() {
 	flag.BoolVar(&debug, "debug", false, "Enable debug mode.")
 	flag.BoolVarP(&showVersion, "version", "v", false, "Report version string and exit.")
 	flag.StringVar(&webListenAddress, "web.listen-address", "0.0.0.0:9520", "Exporter listening address.")
+	flag.StringVar(&webConfigFile, "web.config.file", "", "Path to a web config file (in the format of the Prometheus exporter-toolkit) that enables TLS and/or authentication.")
+	flag.BoolVar(&enableLifecycle, "web.enable-lifecycle", false, "Enable reloading of the configuration via HTTP request to /-/reload.")
+	flag.StringVar(&configFile, "config.file", "", "Path to the config file with the collector configuration. Flags that are given explicitly override the values from the config file.")
+	flag.StringVar(&stateFile, "state.file", "", "Path to a file where the last known metric values are persisted across restarts.")
+	flag.BoolVar(&once, "once", false, "Update the metric values once, write them to the file given with --output.textfile, and exit.")
+	flag.StringVar(&outputTextfile, "output.textfile", "", "Path to the file that the metric values are written to in --once mode (e.g. for the textfile collector of node_exporter).")
+	flag.StringVar(&recordDir, "record.dir", "", "Path to a directory where the raw output of every swift-recon and swift-dispersion-report invocation is recorded.")
+	flag.StringVar(&replayDir, "replay.dir", "", "Path to a directory with recordings (see --record.dir) that are served instead of executing swift-recon and swift-dispersion-report.")
+	flag.StringVar(&pushGatewayURL, "push.gateway-url", "", "URL of a Prometheus Pushgateway that the metric values are pushed to after each update.")
+	flag.StringVar(&pushJob, "push.job", "swift-health-exporter", "Job name for pushing to the Pushgateway.")
+	flag.StringToStringVar(&pushGrouping, "push.grouping", nil, "Grouping labels for pushing to the Pushgateway, e.g. 'cluster=eu-de-1,region=eu-de'.")
+	flag.StringVar(&statsdAddress, "statsd.address", "", "Address (host:port) of a StatsD server that the metric values are sent to after each update.")
+	flag.StringVar(&statsdPrefix, "statsd.prefix", "swift", "Prefix for all names that are sent to the StatsD server.")
+	flag.StringVar(&statsdMappingFile, "statsd.mapping-file", "", "Path to a file that maps metrics to StatsD names.")
+	flag.StringVar(&otlpEndpoint, "otlp.endpoint", "", "URL of the OTLP/HTTP metrics endpoint (e.g. 'http://otel-collector:4318/v1/metrics') that the metric values are sent to after each update.")
+	flag.StringToStringVar(&otlpResourceAttributes, "otlp.resource-attributes", nil, "Additional resource attributes for OTLP, e.g. 'cluster=eu-de-1'.")
+	flag.StringVar(&remoteWriteURL, "remote-write.url", "", "URL of a Prometheus remote-write endpoint that the metric values are written to after each update.")
+	flag.StringToStringVar(&remoteWriteExternalLabels, "remote-write.external-labels", nil, "Labels that are added to all series for remote write, e.g. 'cluster=eu-de-1'.")
+	flag.DurationVar(&remoteWriteMaxBufferAge, "remote-write.max-buffer-age", 15*time.Minute, "Maximum duration for which samples are buffered while the remote-write endpoint is unavailable.")
+	flag.StringVar(&alertingWebhookURL, "alerting.webhook-url", "", "URL of a webhook that alerts are posted to in the Alertmanager webhook format.")
+	flag.StringToStringVar(&alertingLabels, "alerting.labels", nil, "Labels that are added to all alerts, e.g. 'cluster=eu-de-1'.")
+	flag.DurationVar(&alertingRepeatInterval, "alerting.repeat-interval", 4*time.Hour, "How long to wait before notifying again about an alert that is still firing.")
+	flag.DurationVar(&alertingReplicationAgeThreshold, "alerting.replication-age-threshold", 2*time.Hour, "Time since the last replication after which the SwiftReplicationAgeHigh alert fires.")
+	flag.StringVar(&probeConfigFile, "probe.config-file", "", "Path to the config file with the Swift clusters that can be probed through the /probe endpoint.")
 
 	flag.IntVar(&maxFailures, "collector.max-failures", 4, "Max allowed failures for a specific collector.")
 
+	flag.BoolVar(&scrapeOnDemand, "scrape.on-demand", false, "Update metric values synchronously when Prometheus scrapes the exporter instead of in a background loop.")
+	flag.Int64Var(&scrapeMinAge, "scrape.min-age", 30, "Minimum age (in seconds) of the metric values before they are updated again in on-demand mode.")
+	flag.Int64Var(&scrapeTimeout, "scrape.timeout", 60, "Timeout value (in seconds) for updating the metric values in on-demand mode if the scrape does not provide the X-Prometheus-Scrape-Timeout-Seconds header.")
+	flag.Float64Var(&scrapeTimeoutOffset, "scrape.timeout-offset", 0.5, "Offset (in seconds) to subtract from the timeout provided by the scrape in on-demand mode.")
+
 	flag.Int64Var(&dispersionTimeout, "dispersion.timeout", 20, "Timeout value (in seconds) for the context that is used while executing the swift-dispersion-report command.")
 	flag.BoolVar(&dispersionCollector, "collector.dispersion", false, "Enable dispersion collector.")
 
@@ -80,69 +162,240 @@ func main() {
 
 	logg.ShowDebug = debug || osext.GetenvBool("DEBUG")
 
-	reconCollectorEnabled := !(noReconMD5Collector) ||
-		reconDiskUsageCollector ||
-		reconDriveAuditCollector ||
-		reconQuarantinedCollector ||
-		reconReplicationCollector ||
-		reconShardingCollector ||
-		reconUnmountedCollector ||
-		reconUpdaterSweepTimeCollector
+	if webConfigFile != "" {
+		err := web.Validate(webConfigFile)
+		if err != nil {
+			logg.Fatal(err.Error())
+		}
+	}
+
+	switch {
+	case recordDir != "" && replayDir != "":
+		logg.Fatal("--record.dir and --replay.dir cannot be given together")
+	case recordDir != "":
+		err := util.StartRecording(recordDir)
+		if err != nil {
+			logg.Fatal(err.Error())
+		}
+	case replayDir != "":
+		err := util.StartReplay(replayDir)
+		if err != nil {
+			logg.Fatal(err.Error())
+		}
+	}
+
+	// Collect the overrides from the flags that were given explicitly.
+	var overrides config.Overrides
+	isFlagGiven := flag.CommandLine.Changed
+	if isFlagGiven("collector.max-failures") {
+		overrides.MaxFailures = &maxFailures
+	}
+	overrides.Enabled = make(map[string]bool)
+	if isFlagGiven("no-collector.recon.md5") {
+		overrides.Enabled["recon.md5"] = !noReconMD5Collector
+	}
+	for name, enabled := range map[string]bool{
+		"dispersion":               dispersionCollector,
+		"recon.diskusage":          reconDiskUsageCollector,
+		"recon.driveaudit":         reconDriveAuditCollector,
+		"recon.quarantined":        reconQuarantinedCollector,
+		"recon.replication":        reconReplicationCollector,
+		"recon.sharding":           reconShardingCollector,
+		"recon.unmounted":          reconUnmountedCollector,
+		"recon.updater_sweep_time": reconUpdaterSweepTimeCollector,
+	} {
+		if isFlagGiven("collector." + name) {
+			overrides.Enabled[name] = enabled
+		}
+	}
+	if isFlagGiven("dispersion.timeout") {
+		timeout := time.Duration(dispersionTimeout) * time.Second
+		overrides.DispersionTimeout = &timeout
+	}
+	if isFlagGiven("recon.timeout") {
+		timeout := time.Duration(reconTimeout) * time.Second
+		overrides.ReconTimeout = &timeout
+	}
+	if isFlagGiven("recon.timeout-host") {
+		timeout := time.Duration(reconHostTimeout) * time.Second
+		overrides.ReconHostTimeout = &timeout
+	}
+
+	// loadConfig loads the config file (if any) and applies the overrides.
+	loadConfig := func() (config.Config, error) {
+		cfg := config.Default()
+		if configFile != "" {
+			var err error
+			cfg, err = config.Load(configFile)
+			if err != nil {
+				return config.Config{}, err
+			}
+		}
+		overrides.Apply(&cfg)
+		return cfg, cfg.Validate()
+	}
 
-	if !reconCollectorEnabled && !(dispersionCollector) {
-		logg.Fatal("no collector enabled")
+	cfg, err := loadConfig()
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+	factory, err := newTaskFactory(cfg)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+
+	if once || outputTextfile != "" {
+		switch {
+		case !once || outputTextfile == "":
+			logg.Fatal("--once and --output.textfile must be given together")
+		case stateFile != "":
+			logg.Fatal("--state.file is not supported in --once mode")
+		}
+		ctx := httpext.ContextWithSIGINT(context.Background(), 1*time.Second)
+		os.Exit(runOnce(ctx, factory, outputTextfile))
 	}
 
-	registry := prometheus.DefaultRegisterer
-	c := collector.New()
-	s := collector.NewScraper(maxFailures)
+	onDemandOpts := collector.OnDemandOpts{
+		MinAge:         time.Duration(scrapeMinAge) * time.Second,
+		DefaultTimeout: time.Duration(scrapeTimeout) * time.Second,
+		TimeoutOffset:  time.Duration(scrapeTimeoutOffset * float64(time.Second)),
+	}
 
-	if dispersionCollector {
-		execPath := getExecutablePath("SWIFT_DISPERSION_REPORT_PATH", "swift-dispersion-report")
-		t := time.Duration(dispersionTimeout) * time.Second
-		exitCode := dispersion.GetTaskExitCodeGaugeVec(registry)
-		addTask(true, c, s, dispersion.NewReportTask(execPath, t), exitCode)
+	local := newTarget(prometheus.DefaultRegisterer, probe.Cluster{}, factory)
+	if scrapeOnDemand {
+		opts := onDemandOpts
+		opts.Scraper = local.scraper
+		local.collector.OnDemand = &opts
 	}
 
-	if reconCollectorEnabled {
-		exitCode := recon.GetTaskExitCodeGaugeVec(registry)
-		opts := &recon.TaskOpts{
-			PathToExecutable: getExecutablePath("SWIFT_RECON_PATH", "swift-recon"),
-			HostTimeout:      reconHostTimeout,
-			CtxTimeout:       time.Duration(reconTimeout) * time.Second,
+	isStateRestored := false
+	if stateFile != "" {
+		err := collector.RestoreState(local.collector, local.scraper, stateFile)
+		switch {
+		case err == nil:
+			isStateRestored = true
+		case errors.Is(err, fs.ErrNotExist):
+			logg.Info("state file %s does not exist yet", stateFile)
+		default:
+			logg.Error(err.Error())
 		}
-		addTask(reconDiskUsageCollector, c, s, recon.NewDiskUsageTask(opts), exitCode)
-		addTask(reconDriveAuditCollector, c, s, recon.NewDriveAuditTask(opts), exitCode)
-		addTask(!(noReconMD5Collector), c, s, recon.NewMD5Task(opts), exitCode)
-		addTask(reconQuarantinedCollector, c, s, recon.NewQuarantinedTask(opts), exitCode)
-		addTask(reconReplicationCollector, c, s, recon.NewReplicationTask(opts), exitCode)
-		addTask(reconShardingCollector, c, s, recon.NewShardingTask(opts), exitCode)
-		addTask(reconUnmountedCollector, c, s, recon.NewUnmountedTask(opts), exitCode)
-		addTask(reconUpdaterSweepTimeCollector, c, s, recon.NewUpdaterSweepTask(opts), exitCode)
 	}
 
-	prometheus.MustRegister(c)
+	var sinks []sink.Sink
+	if pushGatewayURL != "" {
+		sinks = append(sinks, sink.Pushgateway{URL: pushGatewayURL, Job: pushJob, Grouping: pushGrouping})
+	}
+	if statsdAddress != "" {
+		s := sink.StatsD{Address: statsdAddress, Prefix: statsdPrefix}
+		if statsdMappingFile != "" {
+			s.Mappings, err = sink.LoadStatsDMappings(statsdMappingFile)
+			if err != nil {
+				logg.Fatal(err.Error())
+			}
+		}
+		sinks = append(sinks, s)
+	}
+	if otlpEndpoint != "" {
+		sinks = append(sinks, sink.OTLP{Endpoint: otlpEndpoint, ResourceAttributes: otlpResourceAttributes})
+	}
+	if remoteWriteURL != "" {
+		sinks = append(sinks, &sink.RemoteWrite{
+			URL:            remoteWriteURL,
+			ExternalLabels: remoteWriteExternalLabels,
+			MaxBufferAge:   remoteWriteMaxBufferAge,
+		})
+	}
+	if alertingWebhookURL != "" {
+		sinks = append(sinks, &sink.Alerter{
+			WebhookURL:     alertingWebhookURL,
+			Rules:          sink.DefaultAlertRules(alertingReplicationAgeThreshold),
+			ExternalLabels: alertingLabels,
+			RepeatInterval: alertingRepeatInterval,
+		})
+	}
+	if len(sinks) > 0 {
+		if scrapeOnDemand {
+			logg.Fatal("pushing metric values is not supported in --scrape.on-demand mode")
+		}
+		pusher := sink.NewPusher(prometheus.DefaultGatherer, prometheus.DefaultRegisterer, sinks...)
+		local.scraper.AfterUpdate = pusher.Push
+	}
 
 	ctx := httpext.ContextWithSIGINT(context.Background(), 1*time.Second)
 
-	// Run the scraper at least once so that the metric values are updated before a
-	// Prometheus scrape.
-	s.UpdateAllMetrics(ctx)
+	if !scrapeOnDemand {
+		// Run the scraper at least once so that the metric values are updated before a
+		// Prometheus scrape. This is not necessary if the last known metric
+		// values have been restored from the state file.
+		if !isStateRestored {
+			local.scraper.UpdateAllMetrics(ctx)
+		}
 
-	// Start scraper loop.
-	go s.Run(ctx)
+		// Start scraper loop.
+		go local.scraper.Run(ctx)
+	}
+
+	// loadProbeConfig loads the probe config file (if any).
+	loadProbeConfig := func() (probe.Config, error) {
+		if probeConfigFile == "" {
+			return probe.Config{}, nil
+		}
+		return probe.LoadConfig(probeConfigFile)
+	}
+
+	probeAPI := &probe.API{}
+	probes := &probeTargets{API: probeAPI, OnDemandOpts: onDemandOpts}
+	probeCfg, err := loadProbeConfig()
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+	probes.update(probeCfg, factory)
+
+	// reload rebuilds all tasks from the config files. It is triggered by
+	// SIGHUP or a request to /-/reload. Nothing is changed if any of the
+	// config files is invalid.
+	var reloadMutex sync.Mutex
+	reload := func() error {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		f, err := newTaskFactory(cfg)
+		if err != nil {
+			return err
+		}
+		probeCfg, err := loadProbeConfig()
+		if err != nil {
+			return err
+		}
+		local.update(f)
+		probes.update(probeCfg, f)
+		return nil
+	}
+	go reloadOnSIGHUP(ctx, reload)
 
 	// Collect HTTP handlers.
 	handler := httpapi.Compose(
-		landingPageAPI{},
+		landingPageAPI{Target: local, ReplicationAgeThreshold: alertingReplicationAgeThreshold},
+		errorsAPI{Errors: local.scraper.Errors},
+		reconDataAPI{Target: local},
+		probeAPI,
+		reloadAPI{IsEnabled: enableLifecycle, Reload: reload},
 		httpapi.WithoutLogging(),
 		pprofapi.API{IsAuthorized: pprofapi.IsRequestFromLocalhost},
 	)
 	smux := http.NewServeMux()
 	smux.Handle("/", handler)
-	smux.Handle("/metrics", promhttp.Handler())
+	if local.collector.OnDemand != nil {
+		smux.Handle("/metrics", local.collector.OnDemand.Middleware(promhttp.Handler()))
+	} else {
+		smux.Handle("/metrics", promhttp.Handler())
+	}
 
-	must.Succeed(httpext.ListenAndServeContext(ctx, webListenAddress, smux))
+	must.Succeed(listenAndServe(ctx, webListenAddress, webConfigFile, smux))
 }
 
 // getExecutablePath gets the path to an executable from the environment
@@ -153,53 +406,128 @@ func main() {
 // provided instead of a path, but we do this manually for two reasons:
 // 1. To terminate the program early in case the executable path could not be found.
 // 2. To save multiple LookPath() calls for the same executable.
-func getExecutablePath(envKey, fileName string) string {
+func getExecutablePath(envKey, fileName string) (string, error) {
 	val := os.Getenv(envKey)
 	if val != "" {
-		return val
+		return val, nil
+	}
+	if util.IsReplaying() {
+		// The executables are not run, so they do not need to be installed.
+		return fileName, nil
 	}
 
-	path, err := exec.LookPath(fileName)
+	return exec.LookPath(fileName)
+}
+
+// runOnce updates all metric values once and writes them to the given file in
+// the Prometheus text format. It returns the exit code for the process, which
+// is non-zero if any task failed.
+func runOnce(ctx context.Context, f taskFactory, path string) int {
+	// There is only one run, so every failure counts, regardless of
+	// --collector.max-failures. This also reports the actual exit codes in
+	// the exit code metrics.
+	f.cfg.MaxFailures = 1
+
+	registry := prometheus.NewRegistry()
+	t := newTarget(registry, probe.Cluster{}, f)
+	t.scraper.UpdateAllMetrics(ctx)
+
+	err := prometheus.WriteToTextfile(path, registry)
 	if err != nil {
-		logg.Fatal(err.Error())
+		logg.Error("could not write metrics to %s: %s", path, err.Error())
+		return 1
+	}
+	if failedTasks := t.scraper.FailedTasks(); len(failedTasks) > 0 {
+		logg.Error("the following collectors failed: %s", strings.Join(failedTasks, ", "))
+		return 1
 	}
+	return 0
+}
 
-	return path
+// reloadOnSIGHUP calls reload whenever the process receives SIGHUP.
+func reloadOnSIGHUP(ctx context.Context, reload func() error) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			err := reload()
+			if err != nil {
+				logg.Error("could not reload configuration: %s", err.Error())
+			} else {
+				logg.Info("configuration reloaded")
+			}
+		}
+	}
 }
 
-type landingPageAPI struct{}
+// newProbeTarget returns a new target for the /probe endpoint. Every probe
+// target has its own registry. Probes are always served in on-demand mode.
+func newProbeTarget(cluster probe.Cluster, f taskFactory, onDemandOpts collector.OnDemandOpts) *target {
+	registry := prometheus.NewRegistry()
+	t := newTarget(registry, cluster, f)
+	onDemandOpts.Scraper = t.scraper
+	t.collector.OnDemand = &onDemandOpts
+	t.handler = onDemandOpts.Middleware(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return t
+}
 
-func (landingPageAPI) AddTo(r *mux.Router) {
-	r.Methods("GET", "HEAD").Path("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		pageBytes := []byte(`<html>
-<head><title>Swift Health Exporter</title></head>
-<body>
-<h1>Swift Health Exporter</h1>
-<p><a href="/metrics">Metrics</a></p>
-<p><a href="https://github.com/sapcc/swift-health-exporter">Source Code</a></p>
-</body>
-</html>`)
+// probeTargets holds the targets of the /probe endpoint.
+type probeTargets struct {
+	API          *probe.API
+	OnDemandOpts collector.OnDemandOpts
+	targets      map[string]*target
+}
 
-		_, err := w.Write(pageBytes)
-		if err != nil {
-			logg.Error(err.Error())
+// update replaces the probe targets. Targets whose cluster config did not
+// change are kept with their state.
+func (p *probeTargets) update(probeCfg probe.Config, f taskFactory) {
+	if p.targets == nil {
+		p.targets = make(map[string]*target)
+	}
+	handlers := make(map[string]http.Handler, len(probeCfg.Clusters))
+	for name, cluster := range probeCfg.Clusters {
+		// The executor is stored as a pointer, so the clusters cannot be
+		// compared with ==.
+		t, exists := p.targets[name]
+		if exists && reflect.DeepEqual(t.cluster, cluster) {
+			t.update(f)
+		} else {
+			t = newProbeTarget(cluster, f, p.OnDemandOpts)
+			p.targets[name] = t
 		}
-	})
+		handlers[name] = t.handler
+	}
+	for name := range p.targets {
+		if _, exists := probeCfg.Clusters[name]; !exists {
+			delete(p.targets, name)
+		}
+	}
+	p.API.SetTargets(handlers)
+}
+
+type reloadAPI struct {
+	IsEnabled bool
+	Reload    func() error
 }
 
-// addTask adds a Task to the given Collector and the Scraper along
-// with its corresponding exit code GaugeVec.
-func addTask(
-	shouldAdd bool,
-	c *collector.Collector,
-	s *collector.Scraper,
-	t collector.Task,
-	exitCode *prometheus.GaugeVec) {
-
-	if shouldAdd {
-		name := t.Name()
-		c.Tasks[name] = t
-		s.Tasks[name] = t
-		s.ExitCodeGaugeVec[name] = exitCode
+func (a reloadAPI) AddTo(r *mux.Router) {
+	if !a.IsEnabled {
+		return
 	}
+	r.Methods("POST", "PUT").Path("/-/reload").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.IdentifyEndpoint(r, "/-/reload")
+		err := a.Reload()
+		if err != nil {
+			logg.Error("could not reload configuration: %s", err.Error())
+			http.Error(w, "could not reload configuration: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logg.Info("configuration reloaded")
+		w.WriteHeader(http.StatusNoContent)
+	})
 }
@@ -4,18 +4,43 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/big"
+	"net"
 	"net/http"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sapcc/go-bits/httpapi"
 	"github.com/sapcc/go-bits/httptest"
+	"github.com/sapcc/go-bits/must"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/sapcc/swift-health-exporter/internal/collector"
-	"github.com/sapcc/swift-health-exporter/internal/collector/dispersion"
-	"github.com/sapcc/swift-health-exporter/internal/collector/recon"
+	"github.com/sapcc/swift-health-exporter/internal/config"
+	"github.com/sapcc/swift-health-exporter/internal/probe"
+	"github.com/sapcc/swift-health-exporter/internal/util"
 )
 
 func TestCollector(t *testing.T) {
@@ -32,8 +57,751 @@ func TestCollectorWithErrors(t *testing.T) {
 		"test/fixtures/failed_collect.prom")
 }
 
+func TestCollectorOnDemand(t *testing.T) {
+	// The mock executables are wrapped to count how often they are run.
+	dir := t.TempDir()
+	countFile := filepath.Join(dir, "invocations")
+	wrap := func(mockPath string) string {
+		path := filepath.Join(dir, filepath.Base(mockPath))
+		script := fmt.Sprintf("#!/bin/sh\necho >> %q\nexec %q \"$@\"\n", countFile, must.ReturnT(filepath.Abs(mockPath))(t))
+		must.SucceedT(t, os.WriteFile(path, []byte(script), 0o755))
+		return path
+	}
+	invocations := func() int {
+		buf, err := os.ReadFile(countFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0
+		}
+		return len(must.ReturnT(buf, err)(t))
+	}
+
+	f := mockTaskFactory(t, wrap("build/mock-swift-dispersion-report"), wrap("build/mock-swift-recon"))
+	clock := f.clock.(*fakeClock)
+	registry := prometheus.NewPedanticRegistry()
+	target := newTarget(registry, probe.Cluster{}, f)
+	c := target.collector
+	c.OnDemand = &collector.OnDemandOpts{
+		Scraper:        target.scraper,
+		MinAge:         time.Minute,
+		DefaultTimeout: 30 * time.Second,
+		TimeoutOffset:  500 * time.Millisecond,
+	}
+
+	// No call to UpdateAllMetrics() here, the scrape itself must update the metric values.
+	h := httptest.NewHandler(c.OnDemand.Middleware(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	scrape := func() httptest.Response {
+		return h.RespondTo(t.Context(), "GET /metrics", httptest.WithHeader("X-Prometheus-Scrape-Timeout-Seconds", "10"))
+	}
+	scrape().ExpectBodyAsInFixture(t, http.StatusOK, "test/fixtures/successful_collect.prom")
+	perUpdate := invocations()
+	if perUpdate == 0 {
+		t.Fatal("expected the first scrape to run the collectors")
+	}
+
+	// A scrape within --scrape.min-age reuses the cached values. (The
+	// replication ages in the fixture would be off if the values were
+	// recomputed at the current time.)
+	clock.Advance(59 * time.Second)
+	scrape().ExpectBodyAsInFixture(t, http.StatusOK, "test/fixtures/successful_collect.prom")
+	if n := invocations(); n != perUpdate {
+		t.Errorf("expected no commands to run within the min age, but %d ran", n-perUpdate)
+	}
+
+	// Afterwards, the next scrape updates the metric values, and concurrent
+	// scrapes wait for that update instead of starting their own.
+	clock.Advance(time.Second)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() { scrape().ExpectStatus(t, http.StatusOK) })
+	}
+	wg.Wait()
+	if n := invocations(); n != 2*perUpdate {
+		t.Errorf("expected concurrent scrapes to be coalesced into one update with %d commands, but %d ran", perUpdate, n-perUpdate)
+	}
+
+	// With a min age of zero, every scrape updates the metric values exactly
+	// once.
+	c.OnDemand.MinAge = 0
+	for range 3 {
+		before := invocations()
+		scrape().ExpectStatus(t, http.StatusOK)
+		if n := invocations() - before; n != perUpdate {
+			t.Errorf("expected a scrape with min age 0 to run %d commands, but %d ran", perUpdate, n)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	onDemandOpts := collector.OnDemandOpts{
+		MinAge:         time.Minute,
+		DefaultTimeout: 30 * time.Second,
+	}
+	api := &probe.API{}
+	api.SetTargets(map[string]http.Handler{
+		"healthy": newProbeTarget(probe.Cluster{}, mockTaskFactory(t,
+			"build/mock-swift-dispersion-report",
+			"build/mock-swift-recon"), onDemandOpts).handler,
+		"broken": newProbeTarget(probe.Cluster{}, mockTaskFactory(t,
+			"build/mock-swift-dispersion-report-with-errors",
+			"build/mock-swift-recon-with-errors"), onDemandOpts).handler,
+	})
+	h := httptest.NewHandler(httpapi.Compose(api, httpapi.WithoutLogging()))
+
+	h.RespondTo(t.Context(), "GET /probe?target=healthy").
+		ExpectBodyAsInFixture(t, http.StatusOK, "test/fixtures/successful_collect.prom")
+	h.RespondTo(t.Context(), "GET /probe?target=broken").
+		ExpectBodyAsInFixture(t, http.StatusOK, "test/fixtures/failed_collect.prom")
+	h.RespondTo(t.Context(), "GET /probe").
+		ExpectText(t, http.StatusBadRequest, "missing target parameter\n")
+	h.RespondTo(t.Context(), "GET /probe?target=unknown").
+		ExpectText(t, http.StatusNotFound, "unknown target: \"unknown\"\n")
+}
+
+func TestErrorLog(t *testing.T) {
+	f := mockTaskFactory(t,
+		"build/mock-swift-dispersion-report-with-errors",
+		"build/mock-swift-recon-with-errors")
+	clock := f.clock.(*fakeClock)
+	s := newTarget(prometheus.NewPedanticRegistry(), probe.Cluster{}, f).scraper
+	h := httptest.NewHandler(httpapi.Compose(errorsAPI{Errors: s.Errors}, httpapi.WithoutLogging()))
+
+	h.RespondTo(t.Context(), "GET /api/errors").ExpectText(t, http.StatusOK, "[]\n")
+	h.RespondTo(t.Context(), "GET /errors").ExpectStatus(t, http.StatusOK)
+
+	// The dispersion report reports 4 errors per run, so the oldest records of
+	// that task must be discarded after a few runs.
+	for range 6 {
+		s.UpdateAllMetrics(t.Context())
+		clock.Advance(time.Hour)
+	}
+	var records []collector.ErrorRecord
+	h.RespondTo(t.Context(), "GET /api/errors").CaptureJSON(&records).ExpectStatus(t, http.StatusOK)
+	countByTask := make(map[string]int)
+	for _, r := range records {
+		countByTask[r.Task]++
+	}
+	if countByTask["disperion-report"] != 20 {
+		t.Errorf("expected 20 records for the dispersion report, got %d", countByTask["disperion-report"])
+	}
+
+	expected := collector.ErrorRecord{
+		Time:     mockNow.Add(5 * time.Hour),
+		Task:     "recon-diskusage",
+		Command:  "swift-recon",
+		Query:    "--timeout=1 --diskusage --verbose",
+		Hostname: "10.0.0.2",
+		Message:  "invalid character '<' looking for beginning of value",
+		Output:   "<urlopen error [Errno 111] ECONNREFUSED>",
+	}
+	if !slices.Contains(records, expected) {
+		t.Errorf("expected record %#v, but got %#v", expected, records)
+	}
+
+	body := h.RespondTo(t.Context(), "GET /errors").BodyString()
+	if !strings.Contains(body, "&lt;urlopen error [Errno 111] ECONNREFUSED&gt;") {
+		t.Errorf("expected the error page to contain the escaped command output, got:\n%s", body)
+	}
+}
+
+func TestLandingPage(t *testing.T) {
+	f := mockTaskFactory(t,
+		"build/mock-swift-dispersion-report-with-errors",
+		"build/mock-swift-recon-with-errors")
+	target := newTarget(prometheus.NewPedanticRegistry(), probe.Cluster{}, f)
+	h := httptest.NewHandler(httpapi.Compose(
+		landingPageAPI{Target: target, ReplicationAgeThreshold: 2 * time.Hour},
+		httpapi.WithoutLogging(),
+	))
+
+	// Before the first update, the page only lists the tasks.
+	body := h.RespondTo(t.Context(), "GET /").BodyString()
+	if !strings.Contains(body, "<td>recon-md5</td>\n<td>1m0s</td>\n<td>never</td>") {
+		t.Errorf("expected recon-md5 to not have run yet, got:\n%s", body)
+	}
+	if strings.Contains(body, "Storage nodes") {
+		t.Errorf("expected no storage nodes before the first update, got:\n%s", body)
+	}
+
+	target.scraper.UpdateAllMetrics(t.Context())
+	h.RespondTo(t.Context(), "GET /").
+		ExpectBodyAsInFixture(t, http.StatusOK, "test/fixtures/landing_page.html")
+}
+
+func TestReconDataAPI(t *testing.T) {
+	f := mockTaskFactory(t,
+		"build/mock-swift-dispersion-report-with-errors",
+		"build/mock-swift-recon-with-errors")
+	f.cfg.Collectors["recon.unmounted"].Enabled = false
+	target := newTarget(prometheus.NewPedanticRegistry(), probe.Cluster{}, f)
+	h := httptest.NewHandler(httpapi.Compose(reconDataAPI{Target: target}, httpapi.WithoutLogging()))
+
+	h.RespondTo(t.Context(), "GET /api/v1/recon/sharding").
+		ExpectText(t, http.StatusOK, `{"task":"recon-sharding","hosts":{}}`+"\n")
+	h.RespondTo(t.Context(), "GET /api/v1/recon/unmounted").
+		ExpectText(t, http.StatusNotFound, "collector \"recon.unmounted\" is not enabled\n")
+	h.RespondTo(t.Context(), "GET /api/v1/recon/unknown").
+		ExpectText(t, http.StatusNotFound, "unknown collector: \"recon.unknown\"\n")
+
+	target.scraper.UpdateAllMetrics(t.Context())
+	for _, name := range []string{"md5", "replication", "sharding"} {
+		h.RespondTo(t.Context(), "GET /api/v1/recon/"+name).
+			ExpectBodyAsInFixture(t, http.StatusOK, "test/fixtures/recon_data_"+name+".json")
+	}
+
+	// The endpoint follows the collectors that are enabled after a reload.
+	f.cfg.Collectors["recon.sharding"].Enabled = false
+	target.update(f)
+	h.RespondTo(t.Context(), "GET /api/v1/recon/sharding").
+		ExpectText(t, http.StatusNotFound, "collector \"recon.sharding\" is not enabled\n")
+}
+
 func testCollector(t *testing.T, dispersionReportPath, reconPath, fixturesPath string) {
-	recon.IsTest = true
+	registry, _, s := setupCollector(t, dispersionReportPath, reconPath)
+	s.UpdateAllMetrics(t.Context())
+
+	h := httptest.NewHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	h.RespondTo(t.Context(), "GET /metrics").
+		ExpectBodyAsInFixture(t, http.StatusOK, fixturesPath)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	must.SucceedT(t, util.StartRecording(dir))
+	testCollector(t,
+		"build/mock-swift-dispersion-report",
+		"build/mock-swift-recon",
+		"test/fixtures/successful_collect.prom")
+	must.SucceedT(t, util.StartRecording(""))
+
+	paths := must.ReturnT(filepath.Glob(filepath.Join(dir, "*.json")))(t)
+	if len(paths) == 0 {
+		t.Fatal("expected recordings, but found none")
+	}
+
+	// The replay must produce the same metrics without running the mock
+	// executables (which do not exist at these paths).
+	must.SucceedT(t, util.StartReplay(dir))
+	defer func() { must.SucceedT(t, util.StartReplay("")) }()
+	testCollector(t,
+		"build/replay/mock-swift-dispersion-report",
+		"build/replay/mock-swift-recon",
+		"test/fixtures/successful_collect.prom")
+}
+
+// TestReconScenarios runs the recon collectors against build/fake-swift-recon
+// for each scenario in test/scenarios, and compares the metrics with the
+// fixture of the same name. For a new scenario, the fixture can be taken from
+// the ".prom.actual" file that is written when the test fails.
+func TestReconScenarios(t *testing.T) {
+	for _, scenarioPath := range must.ReturnT(filepath.Glob("test/scenarios/*.yaml"))(t) {
+		name := strings.TrimSuffix(filepath.Base(scenarioPath), ".yaml")
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("FAKE_SWIFT_RECON_SCENARIO", must.ReturnT(filepath.Abs(scenarioPath))(t))
+
+			// The scenario can restrict the collectors (e.g. to limit the
+			// runtime for slow hosts). By default, all recon collectors run.
+			var scenario struct {
+				Collectors []string `yaml:"collectors"`
+			}
+			must.SucceedT(t, yaml.Unmarshal(must.ReturnT(os.ReadFile(scenarioPath))(t), &scenario))
+			f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/fake-swift-recon")
+			for name, cc := range f.cfg.Collectors {
+				cc.Enabled = config.IsRecon(name) && (len(scenario.Collectors) == 0 || slices.Contains(scenario.Collectors, name))
+			}
+
+			registry := prometheus.NewPedanticRegistry()
+			target := newTarget(registry, probe.Cluster{}, f)
+			target.scraper.UpdateAllMetrics(t.Context())
+
+			h := httptest.NewHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+			h.RespondTo(t.Context(), "GET /metrics").
+				ExpectBodyAsInFixture(t, http.StatusOK, "test/scenarios/"+name+".prom")
+		})
+	}
+}
+
+func TestClock(t *testing.T) {
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/mock-swift-recon")
+	clock := f.clock.(*fakeClock)
+	for name, cc := range f.cfg.Collectors {
+		cc.Enabled = name == "recon.replication"
+		cc.Interval = 5 * time.Minute
+	}
+	registry := prometheus.NewPedanticRegistry()
+	target := newTarget(registry, probe.Cluster{}, f)
+
+	replicationAge := func() float64 {
+		t.Helper()
+		for _, mf := range must.ReturnT(registry.Gather())(t) {
+			if mf.GetName() != "swift_cluster_accounts_replication_age" {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				if m.GetLabel()[0].GetValue() == "10.0.0.1" {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+		t.Fatal("replication age for 10.0.0.1 not found")
+		return 0
+	}
+
+	// The mock reports the last replication at 1577664676.578959.
+	target.scraper.UpdateAllMetrics(t.Context())
+	initialAge := float64(mockNow.Unix()) - 1577664676.578959
+	if age := replicationAge(); age != initialAge {
+		t.Errorf("expected replication age %g, got %g", initialAge, age)
+	}
+
+	// The task is not due for an update before its interval has passed.
+	clock.Advance(time.Minute)
+	target.scraper.UpdateAllMetrics(t.Context())
+	if age := replicationAge(); age != initialAge {
+		t.Errorf("expected replication age %g before the interval has passed, got %g", initialAge, age)
+	}
+	clock.Advance(4 * time.Minute)
+	target.scraper.UpdateAllMetrics(t.Context())
+	if age := replicationAge(); age != initialAge+300 {
+		t.Errorf("expected replication age %g after the interval has passed, got %g", initialAge+300, age)
+	}
+
+	// In on-demand mode, metric values younger than the min age are reused.
+	clock.Advance(5 * time.Minute)
+	target.scraper.UpdateAllMetricsIfOlderThan(t.Context(), 10*time.Minute)
+	if age := replicationAge(); age != initialAge+300 {
+		t.Errorf("expected replication age %g for metric values younger than the min age, got %g", initialAge+300, age)
+	}
+	clock.Advance(5 * time.Minute)
+	target.scraper.UpdateAllMetricsIfOlderThan(t.Context(), 10*time.Minute)
+	if age := replicationAge(); age != initialAge+900 {
+		t.Errorf("expected replication age %g for metric values older than the min age, got %g", initialAge+900, age)
+	}
+
+	// The min age takes precedence over the longer interval of the task.
+	clock.Advance(time.Minute)
+	target.scraper.UpdateAllMetricsIfOlderThan(t.Context(), 30*time.Second)
+	if age := replicationAge(); age != initialAge+960 {
+		t.Errorf("expected replication age %g for a min age below the interval, got %g", initialAge+960, age)
+	}
+}
+
+func TestReload(t *testing.T) {
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/mock-swift-recon")
+	target := newTarget(prometheus.NewPedanticRegistry(), probe.Cluster{}, f)
+	oldTasks := maps.Clone(target.collector.Tasks)
+
+	// Reloading with an unchanged config must keep all tasks.
+	target.update(f)
+	for name, task := range target.collector.Tasks {
+		if oldTasks[name] != task {
+			t.Errorf("expected task %q to be kept on reload", name)
+		}
+	}
+
+	// Changed and disabled collectors must be replaced or removed.
+	f.cfg.Collectors["recon.md5"].Enabled = false
+	f.cfg.Collectors["recon.diskusage"].Timeout = 10 * time.Second
+	target.update(f)
+	if len(target.collector.Tasks) != len(oldTasks)-1 {
+		t.Errorf("expected %d tasks after reload, got %d", len(oldTasks)-1, len(target.collector.Tasks))
+	}
+	for name, task := range target.collector.Tasks {
+		expectChanged := name == "recon-diskusage"
+		if isChanged := oldTasks[name] != task; isChanged != expectChanged {
+			t.Errorf("task %q: expected changed = %t, got %t", name, expectChanged, isChanged)
+		}
+	}
+}
+
+func TestReloadRemovedTask(t *testing.T) {
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report-with-errors", "build/mock-swift-recon-with-errors")
+	registry := prometheus.NewPedanticRegistry()
+	target := newTarget(registry, probe.Cluster{}, f)
+	target.scraper.UpdateAllMetrics(t.Context())
+
+	// tasksWithErrors returns the tasks that have host errors in the metrics
+	// and the tasks that have records in the ErrorLog.
+	tasksWithErrors := func() (inMetrics, inErrorLog map[string]bool) {
+		inMetrics = make(map[string]bool)
+		for _, mf := range must.ReturnT(registry.Gather())(t) {
+			if mf.GetName() != "swift_recon_host_errors" {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				for _, lp := range m.GetLabel() {
+					if lp.GetName() == "task" {
+						inMetrics[lp.GetValue()] = true
+					}
+				}
+			}
+		}
+		inErrorLog = make(map[string]bool)
+		for _, r := range target.scraper.Errors.Records() {
+			inErrorLog[r.Task] = true
+		}
+		return inMetrics, inErrorLog
+	}
+	inMetrics, inErrorLog := tasksWithErrors()
+	if !inMetrics["recon-diskusage"] || !inErrorLog["recon-diskusage"] {
+		t.Fatalf("expected errors for recon-diskusage, got %v in the metrics and %v in the error log", inMetrics, inErrorLog)
+	}
+
+	// The errors of a removed task must not outlive it.
+	f.cfg.Collectors["recon.diskusage"].Enabled = false
+	target.update(f)
+	inMetrics, inErrorLog = tasksWithErrors()
+	if inMetrics["recon-diskusage"] || inErrorLog["recon-diskusage"] {
+		t.Errorf("expected no errors for the removed recon-diskusage task, got %v in the metrics and %v in the error log", inMetrics, inErrorLog)
+	}
+	if !inMetrics["recon-driveaudit"] || !inErrorLog["recon-driveaudit"] {
+		t.Errorf("expected the errors of the other tasks to be kept, got %v in the metrics and %v in the error log", inMetrics, inErrorLog)
+	}
+}
+
+func TestReloadProbeTargets(t *testing.T) {
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/mock-swift-recon")
+	probes := &probeTargets{
+		API:          &probe.API{},
+		OnDemandOpts: collector.OnDemandOpts{MinAge: time.Minute, DefaultTimeout: 30 * time.Second},
+	}
+	// Every load of the probe config file yields a new executor pointer.
+	probeConfig := func(kubectlPod string) probe.Config {
+		return probe.Config{Clusters: map[string]probe.Cluster{
+			"local": {},
+			"remote": {Executor: &util.ExecutorConfig{
+				Type:    "kubectl",
+				Kubectl: util.KubectlExecutor{Pod: kubectlPod},
+			}},
+		}}
+	}
+
+	probes.update(probeConfig("swift-proxy-0"), f)
+	oldTargets := maps.Clone(probes.targets)
+
+	// Reloading with an unchanged config must keep all targets.
+	probes.update(probeConfig("swift-proxy-0"), f)
+	for name, target := range probes.targets {
+		if oldTargets[name] != target {
+			t.Errorf("expected probe target %q to be kept on reload", name)
+		}
+	}
+
+	// A changed executor must replace the target.
+	probes.update(probeConfig("swift-proxy-1"), f)
+	if probes.targets["local"] != oldTargets["local"] || probes.targets["remote"] == oldTargets["remote"] {
+		t.Error("expected only the probe target with the changed executor to be replaced")
+	}
+}
+
+func TestWebConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		must.SucceedT(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	// Generate a self-signed certificate that serves as the server certificate,
+	// the client certificate and the CA for both.
+	key := must.ReturnT(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))(t)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "swift-health-exporter-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER := must.ReturnT(x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key))(t)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: must.ReturnT(x509.MarshalECPrivateKey(key))(t)})
+	writeFile("tls.crt", string(certPEM))
+	writeFile("tls.key", string(keyPEM))
+	hash := must.ReturnT(bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost))(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	startServer := func(webConfigFile string) string {
+		l := must.ReturnT(net.Listen("tcp", "127.0.0.1:0"))(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		result := make(chan error)
+		go func() { result <- serveWithWebConfig(ctx, l, webConfigFile, handler) }()
+		t.Cleanup(func() {
+			cancel()
+			must.SucceedT(t, <-result)
+		})
+		return l.Addr().String()
+	}
+	expectStatus := func(client *http.Client, url, username, password string, expected int) {
+		t.Helper()
+		req := must.ReturnT(http.NewRequestWithContext(t.Context(), http.MethodGet, url, http.NoBody))(t)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("GET %s as %q: unexpected error: %s", url, username, err.Error())
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("GET %s as %q: expected status %d, got %d", url, username, expected, resp.StatusCode)
+		}
+	}
+
+	// Basic auth without TLS.
+	addr := startServer(writeFile("basic-auth.yaml", "basic_auth_users:\n  prometheus: "+string(hash)+"\n"))
+	url := "http://" + addr + "/metrics"
+	expectStatus(http.DefaultClient, url, "", "", http.StatusUnauthorized)
+	expectStatus(http.DefaultClient, url, "prometheus", "wrong", http.StatusUnauthorized)
+	expectStatus(http.DefaultClient, url, "unknown", "secret", http.StatusUnauthorized)
+	expectStatus(http.DefaultClient, url, "prometheus", "secret", http.StatusOK)
+	expectStatus(http.DefaultClient, url, "prometheus", "secret", http.StatusOK) // cached
+
+	// TLS with client certificates (relative paths are relative to the web
+	// config file) and basic auth.
+	addr = startServer(writeFile("tls.yaml", `
+tls_server_config:
+  cert_file: tls.crt
+  key_file: tls.key
+  client_auth_type: RequireAndVerifyClientCert
+  client_ca_file: tls.crt
+basic_auth_users:
+  prometheus: `+string(hash)+"\n"))
+	url = "https://" + addr + "/metrics"
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(certPEM)
+	clientCert := must.ReturnT(tls.X509KeyPair(certPEM, keyPEM))(t)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      rootCAs,
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS12,
+	}}}
+	expectStatus(client, url, "", "", http.StatusUnauthorized)
+	expectStatus(client, url, "prometheus", "secret", http.StatusOK)
+
+	clientWithoutCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}}}
+	req := must.ReturnT(http.NewRequestWithContext(t.Context(), http.MethodGet, url, http.NoBody))(t)
+	req.SetBasicAuth("prometheus", "secret")
+	resp, err := clientWithoutCert.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Error("expected request without client certificate to fail")
+	}
+	expectStatus(http.DefaultClient, "http://"+addr+"/metrics", "prometheus", "secret", http.StatusBadRequest)
+}
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/mock-swift-recon")
+
+	// Write the state file.
+	target := newTarget(prometheus.NewPedanticRegistry(), probe.Cluster{}, f)
+	err := collector.RestoreState(target.collector, target.scraper, path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	target.scraper.UpdateAllMetrics(t.Context())
+
+	// Restore the state file without updating the metric values.
+	registry := prometheus.NewPedanticRegistry()
+	target = newTarget(registry, probe.Cluster{}, f)
+	err = collector.RestoreState(target.collector, target.scraper, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, mf := range families {
+		if mf.GetName() != "swift_cluster_md5_all" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			found = true
+			if m.GetTimestampMs() == 0 {
+				t.Errorf("expected restored metric %s to have a timestamp", mf.GetName())
+			}
+		}
+	}
+	if !found {
+		t.Error("expected swift_cluster_md5_all to be restored from the state file")
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swift.prom")
+
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/mock-swift-recon")
+	if exitCode := runOnce(t.Context(), f, path); exitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", exitCode)
+	}
+	actual := must.ReturnT(os.ReadFile(path))(t)
+	expected := must.ReturnT(os.ReadFile("test/fixtures/successful_collect.prom"))(t)
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("expected %s to match test/fixtures/successful_collect.prom, got:\n%s", path, actual)
+	}
+
+	// Any failure is reported, even with the default max failures.
+	f = mockTaskFactory(t, "build/does-not-exist", "build/does-not-exist")
+	f.cfg.MaxFailures = config.Default().MaxFailures
+	if exitCode := runOnce(t.Context(), f, path); exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+	actual = must.ReturnT(os.ReadFile(path))(t)
+	if expected := `swift_dispersion_task_exit_code{query="--dump-json"} 1`; !bytes.Contains(actual, []byte(expected)) {
+		t.Errorf("expected %s to contain %q, got:\n%s", path, expected, actual)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		Args             []string
+		ReconPath        string // defaults to build/mock-swift-recon
+		ExpectedExitCode int
+		ExpectedOutput   string
+	}{
+		{
+			Args:             []string{"--metric=swift_cluster_drives_unmounted", "-w", "1", "-c", "2", "recon.unmounted"},
+			ExpectedExitCode: 0,
+			ExpectedOutput:   "SWIFT RECON.UNMOUNTED OK - 2 values checked | 'swift_cluster_drives_unmounted[10.0.0.1]'=0;1;2 'swift_cluster_drives_unmounted[10.0.0.2]'=1;1;2\n",
+		},
+		{
+			Args:             []string{"--metric=swift_cluster_drives_unmounted", "-w", "0", "-c", "2", "recon.unmounted"},
+			ExpectedExitCode: 1,
+			ExpectedOutput:   "SWIFT RECON.UNMOUNTED WARNING - swift_cluster_drives_unmounted[10.0.0.2] = 1 (warning: 0) | 'swift_cluster_drives_unmounted[10.0.0.1]'=0;0;2 'swift_cluster_drives_unmounted[10.0.0.2]'=1;0;2\n",
+		},
+		{
+			Args:             []string{"-c", "@1:", "recon.unmounted"},
+			ExpectedExitCode: 2,
+			ExpectedOutput:   "SWIFT RECON.UNMOUNTED CRITICAL - swift_cluster_drives_unmounted[10.0.0.2] = 1 (critical: @1:) | 'swift_cluster_drives_unmounted[10.0.0.1]'=0;;@1: 'swift_cluster_drives_unmounted[10.0.0.2]'=1;;@1:\n",
+		},
+		{
+			Args:             []string{"recon.unknown"},
+			ExpectedExitCode: 3,
+			ExpectedOutput:   "SWIFT RECON.UNKNOWN UNKNOWN - unknown collector: recon.unknown\n",
+		},
+		// The host errors are only checked if they are selected explicitly.
+		{
+			Args:             []string{"recon.unmounted"},
+			ReconPath:        "build/mock-swift-recon-with-errors",
+			ExpectedExitCode: 1,
+			ExpectedOutput:   "SWIFT RECON.UNMOUNTED WARNING - query \"--timeout=1 --unmounted --verbose\" reported errors | 'swift_cluster_drives_unmounted[10.0.0.1]'=0;;\n",
+		},
+		{
+			Args:             []string{"--metric=swift_recon_host_errors", "-c", "0", "recon.unmounted"},
+			ReconPath:        "build/mock-swift-recon-with-errors",
+			ExpectedExitCode: 2,
+			ExpectedOutput:   "SWIFT RECON.UNMOUNTED CRITICAL - query \"--timeout=1 --unmounted --verbose\" reported errors, swift_recon_host_errors[timeout,10.0.0.2,recon-unmounted] = 1 (critical: 0) | 'swift_recon_host_errors[timeout,10.0.0.2,recon-unmounted]'=1;;0\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Setenv("SWIFT_RECON_PATH", must.ReturnT(filepath.Abs(cmp.Or(tc.ReconPath, "build/mock-swift-recon")))(t))
+		var buf bytes.Buffer
+		exitCode := runCheck(t.Context(), tc.Args, &buf)
+		if exitCode != tc.ExpectedExitCode {
+			t.Errorf("check %v: expected exit code %d, got %d", tc.Args, tc.ExpectedExitCode, exitCode)
+		}
+		if buf.String() != tc.ExpectedOutput {
+			t.Errorf("check %v: expected output %q, got %q", tc.Args, tc.ExpectedOutput, buf.String())
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for what, fixturePath := range map[string]string{
+		"rules":     "test/fixtures/generated_rules.yaml",
+		"dashboard": "test/fixtures/generated_dashboard.json",
+	} {
+		var stdout, stderr bytes.Buffer
+		exitCode := runGenerate([]string{"--all", what}, &stdout, &stderr)
+		if exitCode != 0 {
+			t.Fatalf("generate %s: expected exit code 0, got %d: %s", what, exitCode, stderr.String())
+		}
+		expected := must.ReturnT(os.ReadFile(fixturePath))(t)
+		if !bytes.Equal(stdout.Bytes(), expected) {
+			t.Errorf("generate %s: output does not match %s, got:\n%s", what, fixturePath, stdout.String())
+		}
+	}
+}
+
+func TestMetricDocs(t *testing.T) {
+	docs := renderMetricDocs()
+	readme := must.ReturnT(os.ReadFile("README.md"))(t)
+	_, readmeDocs, _, err := splitReadme(string(readme))
+	must.SucceedT(t, err)
+	if strings.TrimSpace(readmeDocs) != docs {
+		t.Error(`the metric tables in README.md are out of date, run "go generate" to update them`)
+	}
+
+	// Every task defines all the metrics that it describes...
+	f := mockTaskFactory(t, "build/mock-swift-dispersion-report", "build/mock-swift-recon")
+	registry := prometheus.NewPedanticRegistry()
+	target := newTarget(registry, probe.Cluster{}, f)
+	for name, task := range target.collector.Tasks {
+		ch := make(chan *prometheus.Desc, 100)
+		task.DescribeMetrics(ch)
+		close(ch)
+		if len(ch) != len(task.MetricInfos()) {
+			t.Errorf("task %s describes %d metrics, but defines %d", name, len(ch), len(task.MetricInfos()))
+		}
+	}
+
+	// ...and the definitions match the reported metrics.
+	documented := make(map[string]collector.MetricInfo)
+	for _, metrics := range must.ReturnT(describeCollectors(f.cfg))(t) {
+		for _, info := range metrics {
+			documented[info.Name] = info
+		}
+	}
+	target.scraper.UpdateAllMetrics(t.Context())
+	for _, mf := range must.ReturnT(registry.Gather())(t) {
+		info, exists := documented[mf.GetName()]
+		if !exists {
+			t.Errorf("metric %s is not documented", mf.GetName())
+			continue
+		}
+		if mf.GetType() != info.Type || mf.GetHelp() != info.Help {
+			t.Errorf("expected %s to be a %s with help %q, but it is a %s with help %q", mf.GetName(), info.Type, info.Help, mf.GetType(), mf.GetHelp())
+		}
+		expectedLabels := slices.Sorted(slices.Values(info.Labels))
+		for _, m := range mf.GetMetric() {
+			var labels []string
+			for _, lp := range m.GetLabel() {
+				labels = append(labels, lp.GetName())
+			}
+			if !slices.Equal(labels, expectedLabels) {
+				t.Errorf("expected %s to have the labels %v, but got %v", mf.GetName(), expectedLabels, labels)
+			}
+		}
+	}
+}
+
+func setupCollector(t *testing.T, dispersionReportPath, reconPath string) (*prometheus.Registry, *collector.Collector, *collector.Scraper) {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	target := newTarget(registry, probe.Cluster{}, mockTaskFactory(t, dispersionReportPath, reconPath))
+	return registry, target.collector, target.scraper
+}
+
+// mockNow is the time of the clock in mockTaskFactory, shortly after the last
+// replication that is reported by the mocks.
+var mockNow = time.Date(2020, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+// mockTaskFactory returns a taskFactory with all collectors enabled that uses
+// the given mock executables.
+func mockTaskFactory(t *testing.T, dispersionReportPath, reconPath string) taskFactory {
+	t.Helper()
 
 	dispersionReportAbsPath, err := filepath.Abs(dispersionReportPath)
 	if err != nil {
@@ -44,33 +812,41 @@ func testCollector(t *testing.T, dispersionReportPath, reconPath, fixturesPath s
 		t.Error(err)
 	}
 
-	registry := prometheus.NewPedanticRegistry()
-	c := collector.New()
-	s := collector.NewScraper(0)
-
-	dispersionExitCode := dispersion.GetTaskExitCodeGaugeVec(registry)
-	addTask(true, c, s, dispersion.NewReportTask(dispersionReportAbsPath, 20*time.Second), dispersionExitCode)
-
-	reconExitCode := recon.GetTaskExitCodeGaugeVec(registry)
-	opts := &recon.TaskOpts{
-		PathToExecutable: reconAbsPath,
-		HostTimeout:      1,
-		CtxTimeout:       4 * time.Second,
+	cfg := config.Default()
+	cfg.MaxFailures = 0
+	for name, cc := range cfg.Collectors {
+		cc.Enabled = true
+		if config.IsRecon(name) {
+			cc.ExecutablePath = reconAbsPath
+		} else {
+			cc.ExecutablePath = dispersionReportAbsPath
+		}
+	}
+	f, err := newTaskFactory(cfg)
+	if err != nil {
+		t.Fatal(err)
 	}
-	addTask(true, c, s, recon.NewDiskUsageTask(opts), reconExitCode)
-	addTask(true, c, s, recon.NewDriveAuditTask(opts), reconExitCode)
-	addTask(true, c, s, recon.NewMD5Task(opts), reconExitCode)
-	addTask(true, c, s, recon.NewQuarantinedTask(opts), reconExitCode)
-	addTask(true, c, s, recon.NewReplicationTask(opts), reconExitCode)
-	addTask(true, c, s, recon.NewUnmountedTask(opts), reconExitCode)
-	addTask(true, c, s, recon.NewUpdaterSweepTask(opts), reconExitCode)
-	addTask(true, c, s, recon.NewShardingTask(opts), reconExitCode)
+	f.clock = &fakeClock{now: mockNow}
+	return f
+}
 
-	registry.MustRegister(c)
+// fakeClock is a util.Clock whose time only changes when it is advanced
+// explicitly.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
 
-	s.UpdateAllMetrics(t.Context())
+// Now implements the util.Clock interface.
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
 
-	h := httptest.NewHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
-	h.RespondTo(t.Context(), "GET /metrics").
-		ExpectBodyAsInFixture(t, http.StatusOK, fixturesPath)
+// Advance moves the time of the fakeClock forward by the given duration.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
 }
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sapcc/go-bits/httpapi"
+	"github.com/sapcc/go-bits/logg"
+)
+
+// reconDataAPI serves the data that the recon tasks of the /metrics endpoint
+// have parsed from the swift-recon output in their last update. The endpoint
+// is /api/v1/recon/<name>, where <name> is the collector name without the
+// "recon." prefix (e.g. /api/v1/recon/sharding for recon.sharding).
+type reconDataAPI struct {
+	Target *target
+}
+
+type reconDataResponse struct {
+	Task      string         `json:"task"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"` // nil if the task has not been updated successfully yet
+	Hosts     map[string]any `json:"hosts"`
+}
+
+func (a reconDataAPI) AddTo(r *mux.Router) {
+	r.Methods("GET", "HEAD").Path("/api/v1/recon/{collector}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.IdentifyEndpoint(r, "/api/v1/recon/:collector")
+		name := "recon." + mux.Vars(r)["collector"]
+		if _, exists := reconTasks[name]; !exists {
+			http.Error(w, fmt.Sprintf("unknown collector: %q", name), http.StatusNotFound)
+			return
+		}
+		taskName, exists := a.Target.reconTaskName(name)
+		var (
+			data      map[string]any
+			updatedAt time.Time
+		)
+		if exists {
+			// may still fail if a reload has removed the task in the meantime
+			data, updatedAt, exists = a.Target.collector.TaskData(taskName)
+		}
+		if !exists {
+			http.Error(w, fmt.Sprintf("collector %q is not enabled", name), http.StatusNotFound)
+			return
+		}
+
+		resp := reconDataResponse{Task: taskName, Hosts: data}
+		if data == nil {
+			resp.Hosts = map[string]any{} // render as {} instead of null
+		} else {
+			resp.UpdatedAt = &updatedAt
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			logg.Error(err.Error())
+		}
+	})
+}
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"cmp"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sapcc/swift-health-exporter/internal/collector"
+	"github.com/sapcc/swift-health-exporter/internal/collector/dispersion"
+	"github.com/sapcc/swift-health-exporter/internal/collector/recon"
+	"github.com/sapcc/swift-health-exporter/internal/config"
+	"github.com/sapcc/swift-health-exporter/internal/probe"
+	"github.com/sapcc/swift-health-exporter/internal/util"
+)
+
+// reconTasks maps the names of the recon collectors to their Task constructors.
+var reconTasks = map[string]func(*recon.TaskOpts) collector.Task{
+	"recon.diskusage":          recon.NewDiskUsageTask,
+	"recon.driveaudit":         recon.NewDriveAuditTask,
+	"recon.md5":                recon.NewMD5Task,
+	"recon.quarantined":        recon.NewQuarantinedTask,
+	"recon.replication":        recon.NewReplicationTask,
+	"recon.sharding":           recon.NewShardingTask,
+	"recon.unmounted":          recon.NewUnmountedTask,
+	"recon.updater_sweep_time": recon.NewUpdaterSweepTask,
+}
+
+// taskFactory builds the tasks for a cluster from the collector config.
+type taskFactory struct {
+	cfg config.Config
+	// The executables that are used if they are not configured explicitly.
+	dispersionPath string
+	reconPath      string
+	// clock is used by all tasks and scrapers (optional, defaults to the
+	// system clock). It is only set in tests.
+	clock util.Clock
+}
+
+// newTaskFactory returns a new taskFactory for the given config. The
+// executables that are not configured explicitly are only searched for once.
+//
+// If the commands are not run on the local host, the executables are not
+// searched for, but are left to the PATH on the remote side.
+func newTaskFactory(cfg config.Config) (taskFactory, error) {
+	f := taskFactory{cfg: cfg}
+	if !cfg.Executor.Executor().IsLocal() {
+		f.reconPath = cmp.Or(os.Getenv("SWIFT_RECON_PATH"), "swift-recon")
+		f.dispersionPath = cmp.Or(os.Getenv("SWIFT_DISPERSION_REPORT_PATH"), "swift-dispersion-report")
+		return f, nil
+	}
+	for name, cc := range cfg.Collectors {
+		if !cc.Enabled || cc.ExecutablePath != "" {
+			continue
+		}
+		var err error
+		switch {
+		case config.IsRecon(name) && f.reconPath == "":
+			f.reconPath, err = getExecutablePath("SWIFT_RECON_PATH", "swift-recon")
+		case !config.IsRecon(name) && f.dispersionPath == "":
+			f.dispersionPath, err = getExecutablePath("SWIFT_DISPERSION_REPORT_PATH", "swift-dispersion-report")
+		}
+		if err != nil {
+			return taskFactory{}, err
+		}
+	}
+	return f, nil
+}
+
+// specs returns the specs for all enabled tasks of the given target, and the
+// names of the enabled recon collectors mapped to the names of their tasks.
+func (f taskFactory) specs(t *target) (result []collector.TaskSpec, reconTaskNames map[string]string) {
+	reconTaskNames = make(map[string]string)
+	executor := f.cfg.Executor.Executor()
+	if t.cluster.Executor != nil {
+		executor = t.cluster.Executor.Executor()
+	}
+
+	if cc := f.cfg.Collectors["dispersion"]; cc.Enabled {
+		opts := dispersion.TaskOpts{
+			PathToExecutable: cmp.Or(t.cluster.DispersionReportPath, cc.ExecutablePath, f.dispersionPath),
+			CtxTimeout:       cc.Timeout,
+			ConfigFile:       t.cluster.DispersionConfigFile,
+			Executor:         executor,
+		}
+		if opts.ConfigFile == "" && t.cluster.SwiftDir != "" {
+			opts.ConfigFile = filepath.Join(t.cluster.SwiftDir, "dispersion.conf")
+		}
+		if t.dispersionExitCode == nil {
+			t.dispersionExitCode = dispersion.GetTaskExitCodeGaugeVec(t.registry)
+		}
+		result = append(result, collector.TaskSpec{
+			Task:             dispersion.NewReportTask(&opts),
+			ExitCodeGaugeVec: t.dispersionExitCode,
+			Interval:         cc.Interval,
+			Config:           opts,
+		})
+	}
+
+	for name, newTask := range reconTasks {
+		cc := f.cfg.Collectors[name]
+		if !cc.Enabled {
+			continue
+		}
+		opts := recon.TaskOpts{
+			PathToExecutable: cmp.Or(t.cluster.ReconPath, cc.ExecutablePath, f.reconPath),
+			HostTimeout:      int(cc.HostTimeout / time.Second),
+			CtxTimeout:       cc.Timeout,
+			SwiftDir:         t.cluster.SwiftDir,
+			Options:          cc.Options,
+			Clock:            f.clock,
+			Executor:         executor,
+		}
+		if t.reconExitCode == nil {
+			t.reconExitCode = recon.GetTaskExitCodeGaugeVec(t.registry)
+			t.reconHostErrors = recon.GetHostErrorsGaugeVec(t.registry)
+		}
+		opts.HostErrors = t.reconHostErrors
+		task := newTask(&opts)
+		reconTaskNames[name] = task.Name()
+		result = append(result, collector.TaskSpec{
+			Task:               task,
+			ExitCodeGaugeVec:   t.reconExitCode,
+			HostErrorsGaugeVec: t.reconHostErrors,
+			Interval:           cc.Interval,
+			Config:             opts,
+		})
+	}
+
+	return result, reconTaskNames
+}
+
+// target is a Collector/Scraper pair for a specific cluster together with the
+// registry that the Collector is registered with.
+type target struct {
+	cluster   probe.Cluster // the zero value describes the local cluster
+	registry  prometheus.Registerer
+	collector *collector.Collector
+	scraper   *collector.Scraper
+
+	// The exit code and host error GaugeVecs are registered when the first
+	// task that needs them is added.
+	dispersionExitCode *prometheus.GaugeVec
+	reconExitCode      *prometheus.GaugeVec
+	reconHostErrors    *prometheus.GaugeVec
+
+	// reconTaskNames maps the names of the enabled recon collectors to the
+	// names of their tasks. It is replaced by update().
+	mu             sync.Mutex
+	reconTaskNames map[string]string
+
+	handler http.Handler // only for probe targets, see newProbeTarget()
+}
+
+// newTarget returns a new target with all enabled tasks for the given cluster.
+func newTarget(registry prometheus.Registerer, cluster probe.Cluster, f taskFactory) *target {
+	t := &target{
+		cluster:   cluster,
+		registry:  registry,
+		collector: collector.New(),
+		scraper:   collector.NewScraper(f.cfg.MaxFailures),
+	}
+	t.scraper.Clock = f.clock
+	t.update(f)
+	registry.MustRegister(t.collector)
+	return t
+}
+
+// update replaces the tasks of the target with the ones from the given
+// taskFactory. Tasks whose config did not change are kept with their state.
+func (t *target) update(f taskFactory) {
+	t.scraper.SetMaxFailures(f.cfg.MaxFailures)
+	specs, reconTaskNames := f.specs(t)
+	collector.ReplaceTasks(t.collector, t.scraper, specs)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reconTaskNames = reconTaskNames
+}
+
+// reconTaskName returns the name of the task of the given recon collector, or
+// false if that collector is not enabled.
+func (t *target) reconTaskName(collectorName string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	taskName, exists := t.reconTaskNames[collectorName]
+	return taskName, exists
+}
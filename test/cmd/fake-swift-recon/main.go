@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+// fake-swift-recon emulates the output of swift-recon for the Swift cluster
+// that is described in the scenario file given in $FAKE_SWIFT_RECON_SCENARIO.
+//
+// Like the real swift-recon, it queries the recon endpoints of all hosts over
+// HTTP. The endpoints are served from an HTTP server within the same process,
+// so that unreachable and slow hosts behave like in a real cluster.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Ports of the Swift servers by server type.
+var serverPorts = map[string]int{
+	"account":   6002,
+	"container": 6001,
+	"object":    6000,
+}
+
+const separator = "==============================================================================="
+
+func main() {
+	var (
+		timeout     int
+		swiftDir    string
+		verbose     bool
+		diskusage   bool
+		driveaudit  bool
+		md5         bool
+		quarantined bool
+		replication bool
+		sharding    bool
+		unmounted   bool
+		updater     bool
+	)
+
+	flag.IntVarP(&timeout, "timeout", "t", 5, "Time to wait for a response from a server.")
+	flag.StringVar(&swiftDir, "swiftdir", "/etc/swift", "Default = /etc/swift")
+	flag.BoolVarP(&verbose, "verbose", "v", false, "Print verbose info.")
+	flag.BoolVarP(&diskusage, "diskusage", "d", false, "Get disk usage stats.")
+	flag.BoolVar(&driveaudit, "driveaudit", false, "Get drive audit error stats.")
+	flag.BoolVar(&md5, "md5", false, "Get md5sum of servers ring and compare to local copy.")
+	flag.BoolVarP(&quarantined, "quarantined", "q", false, "Get cluster quarantine stats.")
+	flag.BoolVarP(&replication, "replication", "r", false, "Get replication stats.")
+	flag.BoolVarP(&sharding, "sharding", "s", false, "Check container sharding stats.")
+	flag.BoolVarP(&unmounted, "unmounted", "u", false, "Check cluster for unmounted devices.")
+	flag.BoolVar(&updater, "updater", false, "Get updater stats.")
+	flag.Parse()
+
+	serverType := "object"
+	if flag.NArg() > 0 {
+		serverType = flag.Arg(0)
+	}
+	if _, exists := serverPorts[serverType]; !exists {
+		fail(fmt.Errorf("invalid server type: %q", serverType))
+	}
+
+	scenarioPath := os.Getenv("FAKE_SWIFT_RECON_SCENARIO")
+	if scenarioPath == "" {
+		fail(errors.New("FAKE_SWIFT_RECON_SCENARIO is not set"))
+	}
+	s, err := loadScenario(scenarioPath)
+	if err != nil {
+		fail(err)
+	}
+
+	// Start the HTTP server for the recon endpoints. The client connects to
+	// it for all hosts and selects the host through the Host header.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fail(err)
+	}
+	go http.Serve(listener, s) //nolint:errcheck,gosec // runs until the process exits
+	var dialer net.Dialer
+	r := recon{
+		scenario:   s,
+		serverType: serverType,
+		verbose:    verbose,
+		client: &http.Client{
+			Timeout: time.Duration(timeout) * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, listener.Addr().String())
+				},
+			},
+		},
+	}
+
+	fmt.Println(separator)
+	fmt.Printf("--> Starting reconnaissance on %d hosts (%s)\n", len(s.Hosts), serverType)
+	fmt.Println(separator)
+	switch {
+	case diskusage:
+		r.check("Checking disk usage now", "diskusage")
+	case driveaudit:
+		r.check("Checking drive-audit errors", "driveaudit")
+	case md5:
+		r.checkMD5()
+	case quarantined:
+		r.check("Checking quarantine", "quarantined")
+	case replication:
+		r.check("Checking on replication", "replication/"+serverType)
+	case sharding:
+		r.check("Checking on sharders", "sharding")
+	case unmounted:
+		r.check("Getting unmounted drives from hosts...", "unmounted")
+	case updater:
+		r.check("Checking updater times", "updater/"+serverType)
+	}
+	fmt.Println(separator)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "fake-swift-recon: "+err.Error())
+	os.Exit(1)
+}
+
+type recon struct {
+	scenario   scenario
+	serverType string
+	verbose    bool
+	client     *http.Client
+}
+
+// scout queries the given endpoint of a host. It returns the URL and either
+// the response body, or the error message that swift-recon would print instead.
+func (r recon) scout(h host, endpoint string) (url string, body []byte, err error) {
+	url = fmt.Sprintf("http://%s:%d/recon/%s", h.Address, serverPorts[r.serverType], endpoint)
+	resp, err := r.client.Get(url)
+	if err != nil {
+		if os.IsTimeout(err) {
+			return url, nil, errors.New("<urlopen error timed out>")
+		}
+		return url, nil, errors.New("<urlopen error [Errno 111] ECONNREFUSED>")
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return url, nil, errors.New("<urlopen error timed out>")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return url, nil, fmt.Errorf("HTTP Error %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return url, body, nil
+}
+
+// format returns the response body as swift-recon prints it, i.e. the decoded
+// JSON formatted like Python's repr().
+func (r recon) format(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	err := dec.Decode(&value)
+	if err != nil {
+		// The real swift-recon would crash on malformed JSON. Printing the
+		// body as-is emulates a host that returns garbage instead.
+		return strings.TrimSpace(string(body))
+	}
+	return pyRepr(value, r.scenario.Python2)
+}
+
+func (r recon) check(title, endpoint string) {
+	fmt.Printf("[%s] %s\n", time.Now().UTC().Format(time.DateTime), title)
+	for _, h := range r.scenario.Hosts {
+		url, body, err := r.scout(h, endpoint)
+		switch {
+		case err != nil:
+			fmt.Printf("-> %s: %s\n", url, err.Error())
+		case r.verbose:
+			fmt.Printf("-> %s: %s\n", url, r.format(body))
+		}
+	}
+}
+
+// checkMD5 compares the md5sums reported by the hosts with the ones on disk.
+func (r recon) checkMD5() {
+	for idx, kind := range []struct{ Title, Endpoint, File string }{
+		{"Checking ring md5sums", "ringmd5", r.serverType + ".ring.gz"},
+		{"Checking swift.conf md5sum", "swiftconfmd5", "swift.conf"},
+	} {
+		if idx > 0 {
+			fmt.Println(separator)
+		}
+		fmt.Printf("[%s] %s\n", time.Now().UTC().Format(time.DateTime), kind.Title)
+		fmt.Printf("-> On disk %s md5sum: %s\n", kind.File, r.scenario.MD5Sums[kind.File])
+
+		var matched, errCount int
+		var results []string
+		for _, h := range r.scenario.Hosts {
+			url, body, err := r.scout(h, kind.Endpoint)
+			var sums map[string]string
+			if err == nil {
+				err = json.Unmarshal(body, &sums)
+			}
+			if err != nil {
+				fmt.Printf("-> %s: %s\n", url, err.Error())
+				errCount++
+				continue
+			}
+			if r.verbose {
+				fmt.Printf("-> %s: %s\n", url, r.format(body))
+			}
+
+			isMatch := true
+			for _, filePath := range slices.Sorted(maps.Keys(sums)) {
+				expected, exists := r.scenario.MD5Sums[path.Base(filePath)]
+				if exists && expected != sums[filePath] {
+					results = append(results, fmt.Sprintf("!! %s (%s => %s) doesn't match on disk md5sum", url, filePath, sums[filePath]))
+					isMatch = false
+				}
+			}
+			if isMatch {
+				results = append(results, fmt.Sprintf("-> %s matches.", url))
+				matched++
+			}
+		}
+		for _, line := range results {
+			fmt.Println(line)
+		}
+		fmt.Printf("%d/%d hosts matched, %d error[s] while checking hosts.\n", matched, len(r.scenario.Hosts), errCount)
+	}
+}
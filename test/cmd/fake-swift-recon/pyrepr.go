@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// pyRepr formats a decoded JSON value like Python's repr() does, which is how
+// swift-recon prints the results of the recon endpoints.
+func pyRepr(value any, python2 bool) string {
+	switch value := value.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if value {
+			return "True"
+		}
+		return "False"
+	case json.Number:
+		return value.String()
+	case string:
+		return pyStringRepr(value, python2)
+	case []any:
+		items := make([]string, len(value))
+		for idx, item := range value {
+			items[idx] = pyRepr(item, python2)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		items := make([]string, 0, len(value))
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			items = append(items, pyStringRepr(key, python2)+": "+pyRepr(value[key], python2))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		panic(fmt.Sprintf("unexpected JSON value of type %T", value))
+	}
+}
+
+// pyStringRepr quotes a string like Python does: Single quotes are used unless
+// the string contains single quotes but no double quotes. Python 3 prints
+// printable non-ASCII characters as-is, Python 2 escapes them.
+func pyStringRepr(s string, python2 bool) string {
+	quote := '\''
+	if strings.ContainsRune(s, '\'') && !strings.ContainsRune(s, '"') {
+		quote = '"'
+	}
+
+	var b strings.Builder
+	if python2 {
+		b.WriteRune('u')
+	}
+	b.WriteRune(quote)
+	for _, r := range s {
+		switch {
+		case r == quote || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && (python2 || !unicode.IsPrint(r))):
+			switch {
+			case r < 0x100:
+				fmt.Fprintf(&b, `\x%02x`, r)
+			case r < 0x10000:
+				fmt.Fprintf(&b, `\u%04x`, r)
+			default:
+				fmt.Fprintf(&b, `\U%08x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune(quote)
+	return b.String()
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// scenario describes the Swift cluster that the fake swift-recon reports on.
+type scenario struct {
+	// Collectors lists the collectors that the scenario is meant for. It is
+	// not used by the fake itself, but by the test harness in main_test.go.
+	Collectors []string `yaml:"collectors"`
+	// Python2 formats strings like Python 2 does (u'...').
+	Python2 bool `yaml:"python2"`
+	// MD5Sums contains the md5sums of the files on disk (e.g. "object.ring.gz"
+	// or "swift.conf") that the ringmd5 and swiftconfmd5 results are compared to.
+	MD5Sums map[string]string `yaml:"md5sums"`
+	Hosts   []host            `yaml:"hosts"`
+}
+
+// host is a Swift storage node. All maps are keyed by the recon endpoint
+// (e.g. "diskusage" or "replication/object").
+type host struct {
+	Address string `yaml:"address"`
+	// Down makes all requests fail as if the host were unreachable.
+	Down bool `yaml:"down"`
+	// Delay is applied to all responses. If it exceeds the --timeout of
+	// swift-recon, the requests time out.
+	Delay time.Duration `yaml:"delay"`
+	// Recon contains the results of the recon endpoints, which are served as JSON.
+	Recon map[string]any `yaml:"recon"`
+	// Raw contains response bodies that are served verbatim (e.g. malformed JSON).
+	Raw map[string]string `yaml:"raw"`
+	// Status contains HTTP error status codes that are returned instead of a result.
+	Status map[string]int `yaml:"status"`
+}
+
+func loadScenario(path string) (scenario, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return scenario{}, err
+	}
+	defer file.Close()
+
+	var s scenario
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+	err = dec.Decode(&s)
+	if err != nil {
+		return scenario{}, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ServeHTTP serves the recon endpoints of all hosts. The host is selected
+// through the Host header, e.g. "10.0.0.1:6000".
+func (s scenario) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hostname, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		hostname = r.Host
+	}
+	endpoint, ok := strings.CutPrefix(r.URL.Path, "/recon/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	for _, h := range s.Hosts {
+		if h.Address != hostname {
+			continue
+		}
+
+		if h.Down {
+			// Close the connection without responding.
+			conn, _, err := http.NewResponseController(w).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(h.Delay):
+		}
+
+		if status, exists := h.Status[endpoint]; exists {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		if raw, exists := h.Raw[endpoint]; exists {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(raw))
+			return
+		}
+		if result, exists := h.Recon[endpoint]; exists {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result) //nolint:errcheck // the client will notice
+			return
+		}
+		break
+	}
+	http.NotFound(w, r)
+}
@@ -12,6 +12,7 @@ import (
 func main() {
 	var (
 		timeout     int
+		swiftDir    string
 		serverType  string
 		verbose     bool
 		diskusage   bool
@@ -25,6 +26,7 @@ func main() {
 	)
 
 	flag.IntVarP(&timeout, "timeout", "t", 0, "Time to wait for a response from a server.")
+	flag.StringVar(&swiftDir, "swiftdir", "/etc/swift", "Default = /etc/swift")
 	flag.BoolVarP(&verbose, "verbose", "v", false, "Print verbose info.")
 	flag.BoolVarP(&diskusage, "diskusage", "d", false, "Get disk usage stats.")
 	flag.BoolVar(&driveaudit, "driveaudit", false, "Get drive audit error stats.")
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"github.com/sapcc/go-bits/httpext"
+	"github.com/sapcc/go-bits/logg"
+)
+
+// Timeouts for reading requests when serving with a web config file. Unlike
+// httpext.ListenAndServeContext(), which sets no timeouts, this server may be
+// exposed beyond the cluster, so slow clients must not be able to tie up
+// connections indefinitely. There is no write timeout because probes can
+// take as long as the slowest collector.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+)
+
+// listenAndServe is like httpext.ListenAndServeContext(), but if a web config
+// file is given, TLS and authentication are applied to all requests as
+// configured in that file.
+func listenAndServe(ctx context.Context, addr, webConfigFile string, handler http.Handler) error {
+	if webConfigFile == "" {
+		return httpext.ListenAndServeContext(ctx, addr, handler)
+	}
+	server := newWebConfigServer(handler)
+	flags := &web.FlagConfig{
+		WebListenAddresses: &[]string{addr},
+		WebConfigFile:      &webConfigFile,
+	}
+	return serveUntilDone(ctx, server, func() error {
+		return web.ListenAndServe(server, flags, slog.Default())
+	})
+}
+
+// serveWithWebConfig is like listenAndServe(), but serves on the given
+// listener.
+func serveWithWebConfig(ctx context.Context, l net.Listener, webConfigFile string, handler http.Handler) error {
+	server := newWebConfigServer(handler)
+	flags := &web.FlagConfig{WebConfigFile: &webConfigFile}
+	return serveUntilDone(ctx, server, func() error {
+		return web.Serve(l, server, flags, slog.Default())
+	})
+}
+
+func newWebConfigServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+	}
+}
+
+// serveUntilDone runs the given serve function until it fails or the context
+// expires. This works like httpext.ListenAndServeContext(): The shutdown
+// happens in a separate goroutine, and we wait for it to complete before
+// returning.
+func serveUntilDone(ctx context.Context, server *http.Server, serve func() error) error {
+	waitForServerShutdown := make(chan error)
+	shutdownServer := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-shutdownServer:
+		}
+
+		logg.Info("Shutting down HTTP server...")
+
+		ctx, cancel := context.WithTimeout(context.Background(), httpext.ShutdownTimeout)
+		err := server.Shutdown(ctx)
+		cancel()
+		waitForServerShutdown <- err
+	}()
+
+	serveErr := serve()
+	if !errors.Is(serveErr, http.ErrServerClosed) {
+		shutdownServer <- struct{}{}
+	}
+
+	shutdownErr := <-waitForServerShutdown
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		if shutdownErr != nil {
+			return fmt.Errorf("could not shutdown HTTP server: %w", shutdownErr)
+		}
+		return nil
+	}
+	if shutdownErr != nil {
+		logg.Error("Additional error encountered while shutting down server: %s", shutdownErr.Error())
+	}
+	return fmt.Errorf("could not serve HTTP: %w", serveErr)
+}